@@ -0,0 +1,191 @@
+package ordered
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+)
+
+// CollisionPolicy decides what BiMap.Set does when the value is already mapped to another key.
+type CollisionPolicy uint8
+
+const (
+	CollisionError   CollisionPolicy = iota // fail with ErrValueCollision
+	CollisionReplace                        // remove the other key, then set
+)
+
+var ErrValueCollision = errors.New("value already mapped to another key")
+
+// BiMap is a bidirectional map that keeps its entries in insertion order.
+//
+// Keys and values are both unique, so an entry can be looked up from either side
+// in O(1). Setting a value already mapped to another key follows the
+// CollisionPolicy given by WithCollisionPolicy.
+//
+// BiMap is not safe for concurrent use.
+type BiMap[K, V comparable] struct {
+	m       Map[K, V]
+	inverse map[V]K
+	policy  CollisionPolicy
+}
+
+func NewBiMap[K, V comparable](opts ...Option) *BiMap[K, V] {
+	var opt option
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &BiMap[K, V]{
+		m:       *NewMap[K, V](opts...),
+		inverse: make(map[V]K, opt.capacity),
+		policy:  opt.collision,
+	}
+}
+
+// Set maps key to value and value to key.
+//
+// If key already exists, its value is replaced and it keeps its position.
+// If value is mapped to another key, Set returns an error wrapping ErrValueCollision
+// with CollisionError, or removes that key first with CollisionReplace.
+func (b *BiMap[K, V]) Set(key K, value V) error {
+	if other, ok := b.inverse[value]; ok && other != key {
+		if b.policy != CollisionReplace {
+			return fmt.Errorf("%w: value %v of key %v", ErrValueCollision, value, other)
+		}
+		b.m.Del(other)
+	}
+	if old, ok := b.m.TryGet(key); ok {
+		delete(b.inverse, old)
+	}
+	if b.inverse == nil { // zero value BiMap
+		b.inverse = make(map[V]K)
+	}
+	b.m.Set(key, value)
+	b.inverse[value] = key
+	return nil
+}
+
+func (b *BiMap[K, V]) GetByKey(key K) (V, bool) {
+	return b.m.TryGet(key)
+}
+
+func (b *BiMap[K, V]) GetByValue(value V) (K, bool) {
+	key, ok := b.inverse[value]
+	return key, ok
+}
+
+func (b *BiMap[K, V]) ContainsKey(key K) bool {
+	return b.m.Contains(key)
+}
+
+func (b *BiMap[K, V]) ContainsValue(value V) bool {
+	_, ok := b.inverse[value]
+	return ok
+}
+
+// DelByKey removes the entry of key and returns whether it was present.
+func (b *BiMap[K, V]) DelByKey(key K) bool {
+	value, ok := b.m.TryGet(key)
+	if !ok {
+		return false
+	}
+	b.m.Del(key)
+	delete(b.inverse, value)
+	return true
+}
+
+// DelByValue removes the entry of value and returns whether it was present.
+func (b *BiMap[K, V]) DelByValue(value V) bool {
+	key, ok := b.inverse[value]
+	if !ok {
+		return false
+	}
+	b.m.Del(key)
+	delete(b.inverse, value)
+	return true
+}
+
+func (b *BiMap[K, V]) Len() int {
+	return b.m.Len()
+}
+
+func (b *BiMap[K, V]) Keys() []K {
+	return b.m.Keys()
+}
+
+func (b *BiMap[K, V]) Values() []V {
+	return b.m.Values()
+}
+
+// Iter yields the entries in insertion order.
+func (b *BiMap[K, V]) Iter(yield func(key K, value V) bool) {
+	b.m.Iter(yield)
+}
+
+// All returns an iterator over the entries in insertion order.
+func (b *BiMap[K, V]) All() iter.Seq2[K, V] {
+	return b.m.Iter
+}
+
+// Inverse returns a copy of the map with keys and values swapped, in the same order.
+func (b *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	inverse := &BiMap[V, K]{
+		m:       *NewMap[V, K](WithCapacity(b.Len())),
+		inverse: make(map[K]V, b.Len()),
+		policy:  b.policy,
+	}
+	for key, value := range b.m.Iter {
+		inverse.m.Set(value, key)
+		inverse.inverse[key] = value
+	}
+	return inverse
+}
+
+func (b *BiMap[K, V]) Clear() {
+	b.m.Clear()
+	clear(b.inverse)
+}
+
+func (b *BiMap[K, V]) Clone() *BiMap[K, V] {
+	return &BiMap[K, V]{
+		m:       *b.m.Clone(),
+		inverse: maps.Clone(b.inverse),
+		policy:  b.policy,
+	}
+}
+
+// MarshalJSON encodes the map as a JSON object in insertion order, like Map.
+func (b *BiMap[K, V]) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return appendJSON(b)
+}
+
+func (b *BiMap[K, V]) encodeJSON(e *jsonEncoder, depth int) error {
+	if b == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	return b.m.encodeJSON(e, depth)
+}
+
+// UnmarshalJSON decodes a JSON object into the map like Map.UnmarshalJSON,
+// setting the entries in the order they appear in the document.
+//
+// Collisions follow the CollisionPolicy of the map, the map is left
+// unchanged if the document is invalid or an entry collides with CollisionError.
+func (b *BiMap[K, V]) UnmarshalJSON(data []byte) error {
+	decoded := Map[K, V]{nestedMaps: b.m.nestedMaps}
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	result := b.Clone()
+	for key, value := range decoded.Iter {
+		if err := result.Set(key, value); err != nil {
+			return err
+		}
+	}
+	*b = *result
+	return nil
+}
@@ -0,0 +1,185 @@
+package ordered
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newAliasTestMap(opts ...Option) *BiMap[string, string] {
+	b := NewBiMap[string, string](opts...)
+	for _, kv := range [][2]string{{"home", "/"}, {"docs", "/docs"}, {"blog", "/posts"}} {
+		if err := b.Set(kv[0], kv[1]); err != nil {
+			panic(err)
+		}
+	}
+	return b
+}
+
+func TestBiMap(t *testing.T) {
+	t.Run("lookups", func(t *testing.T) {
+		b := newAliasTestMap()
+		require.Equal(t, 3, b.Len())
+
+		v, ok := b.GetByKey("docs")
+		require.True(t, ok)
+		require.Equal(t, "/docs", v)
+		k, ok := b.GetByValue("/posts")
+		require.True(t, ok)
+		require.Equal(t, "blog", k)
+
+		_, ok = b.GetByKey("/docs")
+		require.False(t, ok)
+		_, ok = b.GetByValue("docs")
+		require.False(t, ok)
+
+		require.True(t, b.ContainsKey("home"))
+		require.True(t, b.ContainsValue("/"))
+		require.False(t, b.ContainsValue("home"))
+
+		require.Equal(t, []string{"home", "docs", "blog"}, b.Keys())
+		require.Equal(t, []string{"/", "/docs", "/posts"}, b.Values())
+	})
+
+	t.Run("update key", func(t *testing.T) {
+		b := newAliasTestMap()
+		require.NoError(t, b.Set("docs", "/documentation"))
+		require.NoError(t, b.Set("docs", "/documentation"))
+		require.Equal(t, []string{"home", "docs", "blog"}, b.Keys())
+		require.False(t, b.ContainsValue("/docs"))
+		k, _ := b.GetByValue("/documentation")
+		require.Equal(t, "docs", k)
+	})
+
+	t.Run("collision error", func(t *testing.T) {
+		b := newAliasTestMap()
+		err := b.Set("root", "/")
+		require.ErrorIs(t, err, ErrValueCollision)
+		require.EqualError(t, err, "value already mapped to another key: value / of key home")
+		require.False(t, b.ContainsKey("root"))
+
+		err = b.Set("docs", "/posts")
+		require.ErrorIs(t, err, ErrValueCollision)
+		v, _ := b.GetByKey("docs")
+		require.Equal(t, "/docs", v)
+		require.Equal(t, 3, b.Len())
+	})
+
+	t.Run("collision replace", func(t *testing.T) {
+		b := newAliasTestMap(WithCollisionPolicy(CollisionReplace))
+		require.NoError(t, b.Set("root", "/"))
+		require.Equal(t, []string{"docs", "blog", "root"}, b.Keys())
+		k, _ := b.GetByValue("/")
+		require.Equal(t, "root", k)
+
+		// both the key and the value are taken
+		require.NoError(t, b.Set("docs", "/posts"))
+		require.Equal(t, []string{"docs", "root"}, b.Keys())
+		require.Equal(t, []string{"/posts", "/"}, b.Values())
+		require.False(t, b.ContainsValue("/docs"))
+		require.False(t, b.ContainsKey("blog"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		b := newAliasTestMap()
+		require.True(t, b.DelByKey("home"))
+		require.False(t, b.DelByKey("home"))
+		require.False(t, b.ContainsValue("/"))
+
+		require.True(t, b.DelByValue("/posts"))
+		require.False(t, b.DelByValue("/posts"))
+		require.False(t, b.ContainsKey("blog"))
+
+		require.Equal(t, []string{"docs"}, b.Keys())
+		require.NoError(t, b.Set("home", "/"))
+		require.Equal(t, []string{"docs", "home"}, b.Keys())
+	})
+
+	t.Run("iteration", func(t *testing.T) {
+		b := newAliasTestMap()
+		var keys []string
+		for k := range b.Iter {
+			keys = append(keys, k)
+		}
+		require.Equal(t, []string{"home", "docs", "blog"}, keys)
+
+		var values []string
+		for _, v := range b.All() {
+			values = append(values, v)
+		}
+		require.Equal(t, []string{"/", "/docs", "/posts"}, values)
+	})
+
+	t.Run("inverse, clone and clear", func(t *testing.T) {
+		b := newAliasTestMap()
+		inv := b.Inverse()
+		require.Equal(t, []string{"/", "/docs", "/posts"}, inv.Keys())
+		k, _ := inv.GetByValue("docs")
+		require.Equal(t, "/docs", k)
+
+		clone := b.Clone()
+		require.NoError(t, clone.Set("about", "/about"))
+		require.False(t, b.ContainsKey("about"))
+		require.False(t, b.ContainsValue("/about"))
+
+		b.Clear()
+		require.Zero(t, b.Len())
+		require.False(t, b.ContainsValue("/"))
+		require.Equal(t, 4, clone.Len())
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var b BiMap[int, string]
+		require.NoError(t, b.Set(1, "a"))
+		require.ErrorIs(t, b.Set(2, "a"), ErrValueCollision)
+		k, ok := b.GetByValue("a")
+		require.True(t, ok)
+		require.Equal(t, 1, k)
+	})
+}
+
+func TestBiMap_JSON(t *testing.T) {
+	t.Run("marshal", func(t *testing.T) {
+		data, err := json.Marshal(newAliasTestMap())
+		require.NoError(t, err)
+		require.Equal(t, `{"home":"/","docs":"/docs","blog":"/posts"}`, string(data))
+
+		nested := NewMap[string, any]()
+		nested.Set("aliases", newAliasTestMap())
+		data, err = json.Marshal(nested)
+		require.NoError(t, err)
+		require.Equal(t, `{"aliases":{"home":"/","docs":"/docs","blog":"/posts"}}`, string(data))
+
+		var nilMap *BiMap[string, string]
+		_, err = nilMap.MarshalJSON()
+		require.ErrorIs(t, err, ErrNilOrderedMap)
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		b := NewBiMap[string, string]()
+		require.NoError(t, json.Unmarshal([]byte(`{"home":"/","docs":"/docs","blog":"/posts"}`), b))
+		require.Equal(t, []string{"home", "docs", "blog"}, b.Keys())
+		k, _ := b.GetByValue("/docs")
+		require.Equal(t, "docs", k)
+
+		b2 := NewBiMap[int, string]()
+		require.NoError(t, json.Unmarshal([]byte(`{"2":"b","1":"a"}`), b2))
+		require.Equal(t, []int{2, 1}, b2.Keys())
+	})
+
+	t.Run("unmarshal collision", func(t *testing.T) {
+		b := newAliasTestMap()
+		err := json.Unmarshal([]byte(`{"about":"/about","root":"/"}`), b)
+		require.ErrorIs(t, err, ErrValueCollision)
+		require.Equal(t, []string{"home", "docs", "blog"}, b.Keys())
+		require.False(t, b.ContainsValue("/about"))
+
+		b = newAliasTestMap(WithCollisionPolicy(CollisionReplace))
+		require.NoError(t, json.Unmarshal([]byte(`{"about":"/about","root":"/"}`), b))
+		require.Equal(t, []string{"docs", "blog", "about", "root"}, b.Keys())
+
+		require.Error(t, json.Unmarshal([]byte(`[1]`), b))
+		require.Equal(t, 4, b.Len())
+	})
+}
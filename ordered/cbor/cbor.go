@@ -0,0 +1,366 @@
+// Package cbor encodes and decodes CBOR (RFC 8949), keeping the order of
+// ordered maps and sets on the wire.
+//
+// Ordered containers (Map, Set, Frozen, BiMap, MultiMap, LRU, SyncMap, ...)
+// are written in iteration order and read back in the order of the document.
+// Other values follow the rules of encoding/json where they apply: structs are
+// maps of their exported fields named by the `cbor` tag, Go maps are written
+// with sorted keys and types implementing encoding.TextMarshaler as strings.
+// time.Time is written as an RFC 3339 string with tag 0.
+//
+// Indefinite-length items are supported when decoding. Tags 0 and 1 are decoded
+// as time.Time, other tags are skipped and their content is decoded as is.
+package cbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	_ "github.com/yusing/ds/ordered" // provides the maps decoded into interfaces
+	"github.com/yusing/ds/ordered/internal/codec"
+)
+
+// UnmarshalTypeError describes a value that cannot be decoded into a Go type.
+type UnmarshalTypeError = codec.UnmarshalTypeError
+
+// major types
+const (
+	majorUint byte = iota
+	majorNegInt
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+	majorTag
+	majorSimple
+)
+
+const (
+	tagDateTime  = 0 // RFC 3339 string
+	tagEpochTime = 1 // seconds since the epoch
+
+	indefinite = 31
+	breakByte  = 0xff
+)
+
+// Marshal returns the CBOR encoding of v.
+func Marshal(v any) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append appends the CBOR encoding of v to dst and returns the extended buffer.
+func Append(dst []byte, v any) ([]byte, error) {
+	w := writer{buf: dst}
+	if err := codec.Encode(&w, v, "cbor"); err != nil {
+		return dst, err
+	}
+	return w.buf, nil
+}
+
+// Unmarshal decodes the CBOR data item in data into v, which must be a non-nil pointer.
+//
+// Decoding into an interface stores nil, bool, int64 (uint64 if it overflows),
+// float64, string, []byte, time.Time, []any, and maps as *ordered.Map[string, any],
+// or *ordered.Map[any, any] if any key is not a string.
+func Unmarshal(data []byte, v any) error {
+	r := reader{data: data}
+	if err := codec.Decode(&r, v, "cbor"); err != nil {
+		return err
+	}
+	if r.off != len(data) {
+		return fmt.Errorf("cbor: %d bytes of trailing data", len(data)-r.off)
+	}
+	return nil
+}
+
+type writer struct {
+	buf []byte
+}
+
+// head writes the initial byte of an item of major type and argument n, in its shortest form.
+func (w *writer) head(major byte, n uint64) {
+	major <<= 5
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, major|26), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, major|27), n)
+	}
+}
+
+func (w *writer) Nil() {
+	w.buf = append(w.buf, 0xf6)
+}
+
+func (w *writer) Bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xf5)
+	} else {
+		w.buf = append(w.buf, 0xf4)
+	}
+}
+
+func (w *writer) Int(i int64) {
+	if i >= 0 {
+		w.head(majorUint, uint64(i))
+	} else {
+		w.head(majorNegInt, uint64(-1-i))
+	}
+}
+
+func (w *writer) Uint(u uint64) {
+	w.head(majorUint, u)
+}
+
+func (w *writer) Float32(f float32) {
+	w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xfa), math.Float32bits(f))
+}
+
+func (w *writer) Float64(f float64) {
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xfb), math.Float64bits(f))
+}
+
+func (w *writer) String(s string) {
+	w.head(majorText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) Bytes(b []byte) {
+	w.head(majorBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) Time(t time.Time) {
+	w.head(majorTag, tagDateTime)
+	w.String(t.Format(time.RFC3339Nano))
+}
+
+func (w *writer) ArrayHeader(n int) {
+	w.head(majorArray, uint64(n))
+}
+
+func (w *writer) MapHeader(n int) {
+	w.head(majorMap, uint64(n))
+}
+
+type reader struct {
+	data []byte
+	off  int
+}
+
+// read returns the next n bytes.
+func (r *reader) read(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.off) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.off : r.off+int(n)]
+	r.off += int(n)
+	return b, nil
+}
+
+// head reads the initial byte of an item and its argument.
+// The argument of indefinite-length items is 0.
+func (r *reader) head() (major, info byte, n uint64, err error) {
+	start := r.off
+	b, err := r.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	major, info = b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		if major == majorSimple {
+			return major, info, 0, nil // floats are read by the caller
+		}
+		b, err := r.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return major, info, n, nil
+	case info == indefinite && major != majorUint && major != majorNegInt && major != majorTag:
+		return major, info, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("cbor: invalid initial byte 0x%02x at offset %d", b[0], start)
+}
+
+func (r *reader) Break() (bool, error) {
+	if r.off >= len(r.data) {
+		return false, io.ErrUnexpectedEOF
+	}
+	if r.data[r.off] == breakByte {
+		r.off++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *reader) Next() (codec.Token, error) {
+	for {
+		start := r.off
+		major, info, n, err := r.head()
+		if err != nil {
+			return codec.Token{}, err
+		}
+		switch major {
+		case majorUint:
+			return codec.Token{Kind: codec.KindUint, Uint: n}, nil
+		case majorNegInt:
+			if n > math.MaxInt64 {
+				return codec.Token{}, fmt.Errorf("cbor: negative integer -1-%d overflows int64 at offset %d", n, start)
+			}
+			return codec.Token{Kind: codec.KindInt, Int: -1 - int64(n)}, nil
+		case majorBytes, majorText:
+			b, err := r.bytes(major, info, n)
+			if err != nil {
+				return codec.Token{}, err
+			}
+			kind := codec.KindBytes
+			if major == majorText {
+				kind = codec.KindString
+			}
+			return codec.Token{Kind: kind, Bytes: b}, nil
+		case majorArray, majorMap:
+			kind, size := codec.KindArray, uint64(1)
+			if major == majorMap {
+				kind, size = codec.KindMap, 2
+			}
+			if info == indefinite {
+				return codec.Token{Kind: kind, Len: -1}, nil
+			}
+			if n > uint64(len(r.data)-r.off)/size {
+				return codec.Token{}, io.ErrUnexpectedEOF
+			}
+			return codec.Token{Kind: kind, Len: int(n)}, nil
+		case majorTag:
+			if n == tagDateTime || n == tagEpochTime {
+				return r.time(n, start)
+			}
+			continue // skip other tags
+		default: // majorSimple
+			return r.simple(info, start)
+		}
+	}
+}
+
+// bytes reads the content of a byte or text string, joining the chunks of indefinite ones.
+func (r *reader) bytes(major, info byte, n uint64) ([]byte, error) {
+	if info != indefinite {
+		return r.read(n)
+	}
+	var joined []byte
+	for {
+		if end, err := r.Break(); err != nil || end {
+			return joined, err
+		}
+		start := r.off
+		chunkMajor, chunkInfo, n, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkInfo == indefinite {
+			return nil, fmt.Errorf("cbor: invalid chunk of indefinite-length string at offset %d", start)
+		}
+		chunk, err := r.read(n)
+		if err != nil {
+			return nil, err
+		}
+		joined = append(joined, chunk...)
+	}
+}
+
+func (r *reader) simple(info byte, start int) (codec.Token, error) {
+	switch info {
+	case 20, 21:
+		return codec.Token{Kind: codec.KindBool, Bool: info == 21}, nil
+	case 22, 23: // null, undefined
+		return codec.Token{Kind: codec.KindNil}, nil
+	case 25:
+		b, err := r.read(2)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindFloat, Float: float16(binary.BigEndian.Uint16(b))}, nil
+	case 26:
+		b, err := r.read(4)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindFloat, Float: float64(math.Float32frombits(binary.BigEndian.Uint32(b)))}, nil
+	case 27:
+		b, err := r.read(8)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindFloat, Float: math.Float64frombits(binary.BigEndian.Uint64(b))}, nil
+	case 24:
+		b, err := r.read(1)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		info = b[0]
+	case indefinite:
+		return codec.Token{}, fmt.Errorf("cbor: unexpected break at offset %d", start)
+	}
+	return codec.Token{}, fmt.Errorf("cbor: unsupported simple value %d at offset %d", info, start)
+}
+
+// time reads the content of a date/time tag.
+func (r *reader) time(tag uint64, start int) (codec.Token, error) {
+	tok, err := r.Next()
+	if err != nil {
+		return codec.Token{}, err
+	}
+	var t time.Time
+	switch {
+	case tag == tagDateTime && tok.Kind == codec.KindString:
+		if t, err = time.Parse(time.RFC3339Nano, string(tok.Bytes)); err != nil {
+			return codec.Token{}, fmt.Errorf("cbor: invalid date/time string at offset %d: %w", start, err)
+		}
+	case tag == tagEpochTime && tok.Kind == codec.KindUint && tok.Uint <= math.MaxInt64:
+		t = time.Unix(int64(tok.Uint), 0).UTC()
+	case tag == tagEpochTime && tok.Kind == codec.KindInt:
+		t = time.Unix(tok.Int, 0).UTC()
+	case tag == tagEpochTime && tok.Kind == codec.KindFloat && !math.IsNaN(tok.Float) && !math.IsInf(tok.Float, 0):
+		sec, frac := math.Modf(tok.Float)
+		t = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	default:
+		return codec.Token{}, fmt.Errorf("cbor: invalid content of tag %d at offset %d", tag, start)
+	}
+	return codec.Token{Kind: codec.KindTime, Time: t}, nil
+}
+
+// float16 converts an IEEE 754 half-precision float.
+func float16(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0: // subnormal
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+0x400, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
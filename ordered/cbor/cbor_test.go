@@ -0,0 +1,275 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"io"
+	"math"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yusing/ds/ordered"
+)
+
+func requireEncoding(t *testing.T, want string, v any) {
+	t.Helper()
+	got, err := Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, want, hex.EncodeToString(got), "%#v", v)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func decodeAny(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	require.NoError(t, Unmarshal(mustDecodeHex(t, s), &v))
+	return v
+}
+
+func TestMarshal(t *testing.T) {
+	// vectors from RFC 8949 Appendix A
+	t.Run("primitives", func(t *testing.T) {
+		requireEncoding(t, "00", 0)
+		requireEncoding(t, "17", 23)
+		requireEncoding(t, "1818", 24)
+		requireEncoding(t, "1903e8", 1000)
+		requireEncoding(t, "1a000f4240", 1000000)
+		requireEncoding(t, "1b000000e8d4a51000", 1000000000000)
+		requireEncoding(t, "1bffffffffffffffff", uint64(math.MaxUint64))
+		requireEncoding(t, "20", -1)
+		requireEncoding(t, "3863", -100)
+		requireEncoding(t, "3903e7", -1000)
+		requireEncoding(t, "3b7fffffffffffffff", int64(math.MinInt64))
+		requireEncoding(t, "fa47c35000", float32(100000))
+		requireEncoding(t, "fb3ff199999999999a", 1.1)
+		requireEncoding(t, "f4", false)
+		requireEncoding(t, "f5", true)
+		requireEncoding(t, "f6", nil)
+		requireEncoding(t, "60", "")
+		requireEncoding(t, "6449455446", "IETF")
+		requireEncoding(t, "4401020304", []byte{1, 2, 3, 4})
+		requireEncoding(t, "80", []int{})
+		requireEncoding(t, "83010203", []int{1, 2, 3})
+		requireEncoding(t, "f6", []int(nil))
+		requireEncoding(t, "a201020304", map[int]int{3: 4, 1: 2})
+		requireEncoding(t, "693132372e302e302e31", netip.MustParseAddr("127.0.0.1"))
+		requireEncoding(t, "c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC))
+	})
+
+	t.Run("ordered containers", func(t *testing.T) {
+		om := ordered.NewMap[string, any]()
+		om.Set("z", 1)
+		om.Set("a", []any{true})
+		om.Set("m", 2)
+		om.Del("m")
+		requireEncoding(t, "a2617a01616181f5", om)
+		requireEncoding(t, "f6", (*ordered.Map[string, int])(nil))
+
+		s := ordered.NewSet[int]()
+		s.Add(2)
+		s.Add(-1)
+		requireEncoding(t, "820220", s)
+
+		m := ordered.NewMultiMap[string, int]()
+		m.Add("a", 1)
+		m.Add("b", 2)
+		m.Add("a", 3)
+		requireEncoding(t, "a3616101616202616103", m)
+
+		b := ordered.NewBiMap[string, int]()
+		require.NoError(t, b.Set("b", 1))
+		require.NoError(t, b.Set("a", 2))
+		requireEncoding(t, "a2616201616102", b)
+		requireEncoding(t, "a2616201616102", ordered.NewFrozen[string, int]().With("b", 1).With("a", 2))
+	})
+
+	t.Run("structs", func(t *testing.T) {
+		type config struct {
+			Name   string                    `cbor:"name"`
+			Skip   int                       `cbor:"-"`
+			Empty  string                    `cbor:",omitempty"`
+			Values ordered.Map[string, bool] `cbor:"values"`
+		}
+		c := config{Name: "n", Skip: 1, Values: *ordered.NewMap[string, bool]()}
+		c.Values.Set("y", true)
+		c.Values.Set("x", false)
+		requireEncoding(t, "a2646e616d65616e6676616c756573a26179f56178f4", c)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Marshal(make(chan int))
+		require.EqualError(t, err, "cbor: unsupported type chan int")
+
+		cyclic := []any{nil}
+		cyclic[0] = cyclic
+		_, err = Marshal(cyclic)
+		require.ErrorContains(t, err, "exceeded max depth")
+
+		dst := []byte{0xf6}
+		got, err := Append(dst, make(chan int))
+		require.Error(t, err)
+		require.Equal(t, dst, got)
+	})
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("primitives", func(t *testing.T) {
+		require.Equal(t, int64(1000000000000), decodeAny(t, "1b000000e8d4a51000"))
+		require.Equal(t, uint64(math.MaxUint64), decodeAny(t, "1bffffffffffffffff"))
+		require.Equal(t, int64(-1000), decodeAny(t, "3903e7"))
+		require.Equal(t, 100000.0, decodeAny(t, "fa47c35000"))
+		require.Equal(t, 1.1, decodeAny(t, "fb3ff199999999999a"))
+		require.Equal(t, "IETF", decodeAny(t, "6449455446"))
+		require.Equal(t, []byte{1, 2, 3, 4}, decodeAny(t, "4401020304"))
+		require.Equal(t, []any{int64(1), int64(2), int64(3)}, decodeAny(t, "83010203"))
+		require.Nil(t, decodeAny(t, "f6"))
+		require.Nil(t, decodeAny(t, "f7"))
+	})
+
+	t.Run("float16", func(t *testing.T) {
+		require.Equal(t, 0.0, decodeAny(t, "f90000"))
+		require.True(t, math.Signbit(decodeAny(t, "f98000").(float64)))
+		require.Equal(t, 1.0, decodeAny(t, "f93c00"))
+		require.Equal(t, 65504.0, decodeAny(t, "f97bff"))
+		require.Equal(t, 5.960464477539063e-8, decodeAny(t, "f90001"))
+		require.Equal(t, 0.00006103515625, decodeAny(t, "f90400"))
+		require.Equal(t, -4.0, decodeAny(t, "f9c400"))
+		require.Equal(t, math.Inf(1), decodeAny(t, "f97c00"))
+		require.Equal(t, math.Inf(-1), decodeAny(t, "f9fc00"))
+		require.True(t, math.IsNaN(decodeAny(t, "f97e00").(float64)))
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		want := time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)
+		require.Equal(t, want, decodeAny(t, "c074323031332d30332d32315432303a30343a30305a"))
+		require.Equal(t, want, decodeAny(t, "c11a514b67b0"))
+		require.Equal(t, want.Add(500*time.Millisecond), decodeAny(t, "c1fb41d452d9ec200000"))
+
+		var at time.Time
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "c11a514b67b0"), &at))
+		require.Equal(t, want, at)
+
+		// unknown tags are skipped
+		require.Equal(t, []byte{1, 2, 3, 4}, decodeAny(t, "d74401020304"))
+		require.Equal(t, "http://www.example.com", decodeAny(t, "d82076687474703a2f2f7777772e6578616d706c652e636f6d"))
+	})
+
+	t.Run("indefinite length", func(t *testing.T) {
+		require.Equal(t, []byte{1, 2, 3, 4, 5}, decodeAny(t, "5f42010243030405ff"))
+		require.Equal(t, "streaming", decodeAny(t, "7f657374726561646d696e67ff"))
+		require.Equal(t, []any{}, decodeAny(t, "9fff"))
+		require.Equal(t,
+			[]any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}},
+			decodeAny(t, "9f018202039f0405ffff"),
+		)
+
+		// {_ "b": [_ 2, 3], "a": 1}
+		m := decodeAny(t, "bf61629f0203ff616101ff").(*ordered.Map[string, any])
+		require.Equal(t, []string{"b", "a"}, m.Keys())
+		require.Equal(t, []any{int64(2), int64(3)}, m.Get("b"))
+
+		var s struct {
+			A int
+			B [1]int
+		}
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "bf61629f0203ff616101ff"), &s))
+		require.Equal(t, 1, s.A)
+		require.Equal(t, [1]int{2}, s.B)
+	})
+
+	t.Run("into ordered containers", func(t *testing.T) {
+		om := ordered.NewMap[string, int]()
+		om.Set("a", 1)
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "a2617a181a61610f"), om))
+		require.Equal(t, []string{"a", "z"}, om.Keys())
+		require.Equal(t, []int{15, 26}, om.Values())
+
+		var s *ordered.Set[int]
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "820220"), &s))
+		require.Equal(t, []int{2, -1}, s.Values())
+
+		var m ordered.MultiMap[string, int]
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "a3616101616202616103"), &m))
+		require.Equal(t, []int{1, 3}, m.GetAll("a"))
+
+		var v any
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "a201020304"), &v))
+		require.Equal(t, []any{int64(1), int64(3)}, v.(*ordered.Map[any, any]).Keys())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		type doc struct {
+			Name  string                     `cbor:"name"`
+			At    time.Time                  `cbor:"at"`
+			Items *ordered.Map[string, any]  `cbor:"items"`
+			Tags  *ordered.Set[string]       `cbor:"tags"`
+			Raw   []byte                     `cbor:"raw"`
+			Ratio float32                    `cbor:"ratio"`
+			Index map[int]*ordered.Set[bool] `cbor:"index"`
+		}
+		items := ordered.NewMap[string, any]()
+		items.Set("second", int64(2))
+		items.Set("first", []any{"x", nil, 1.5})
+		tags := ordered.NewSet[string]()
+		tags.Add("b")
+		tags.Add("a")
+		flags := ordered.NewSet[bool]()
+		flags.Add(true)
+		in := doc{
+			Name:  "doc",
+			At:    time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+			Items: items,
+			Tags:  tags,
+			Raw:   []byte("raw"),
+			Ratio: 0.25,
+			Index: map[int]*ordered.Set[bool]{-1: flags},
+		}
+		data, err := Marshal(in)
+		require.NoError(t, err)
+
+		var out doc
+		require.NoError(t, Unmarshal(data, &out))
+		require.Equal(t, in.Name, out.Name)
+		require.True(t, in.At.Equal(out.At))
+		require.Equal(t, []string{"second", "first"}, out.Items.Keys())
+		require.Equal(t, items.Values(), out.Items.Values())
+		require.Equal(t, []string{"b", "a"}, out.Tags.Values())
+		require.Equal(t, in.Raw, out.Raw)
+		require.Equal(t, in.Ratio, out.Ratio)
+		require.Equal(t, []bool{true}, out.Index[-1].Values())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var n uint8
+		var typeErr *UnmarshalTypeError
+		require.ErrorAs(t, Unmarshal(mustDecodeHex(t, "190100"), &n), &typeErr)
+		require.EqualError(t, typeErr, "cbor: cannot unmarshal integer 256 into Go value of type uint8")
+
+		var v any
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "82"), &v), io.ErrUnexpectedEOF)
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "9f01"), &v), io.ErrUnexpectedEOF)
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "9b7fffffffffffffff"), &v), io.ErrUnexpectedEOF)
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "6361"), &v), io.ErrUnexpectedEOF)
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "1c"), &v), "cbor: invalid initial byte 0x1c at offset 0")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "8101ff"), &v), "cbor: 1 bytes of trailing data")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "ff"), &v), "cbor: unexpected break at offset 0")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "f0"), &v), "cbor: unsupported simple value 16 at offset 0")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "f8ff"), &v), "cbor: unsupported simple value 255 at offset 0")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "5f6161ff"), &v), "cbor: invalid chunk of indefinite-length string at offset 1")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "c06161"), &v), `cbor: invalid date/time string at offset 0: parsing time "a" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "a" as "2006"`)
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "c1f6"), &v), "cbor: invalid content of tag 1 at offset 0")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "3bffffffffffffffff"), &v), "cbor: negative integer -1-18446744073709551615 overflows int64 at offset 0")
+		require.ErrorContains(t, Unmarshal(mustDecodeHex(t, "a1810101"), &v), "map key of type []interface {}")
+
+		nested := strings.Repeat("81", 20000) + "f6"
+		require.ErrorContains(t, Unmarshal(mustDecodeHex(t, nested), &v), "exceeded max depth")
+	})
+}
@@ -0,0 +1,232 @@
+package ordered
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ExpiringMap is an ordered map in which every entry expires after a TTL.
+//
+// Expired entries are removed lazily when accessed, by Purge,
+// or periodically by a janitor started with StartJanitor.
+//
+// ExpiringMap is safe for concurrent use.
+type ExpiringMap[K comparable, V any] struct {
+	mu       sync.Mutex
+	m        Map[K, expiringEntry[V]]
+	ttl      time.Duration
+	now      func() time.Time
+	onExpire func(key K, value V)
+}
+
+type expiringEntry[V any] struct {
+	value   V
+	expires time.Time // zero means never
+}
+
+type keyValue[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewExpiringMap creates an ExpiringMap whose entries expire after ttl by default.
+// A ttl <= 0 means entries added with Set never expire.
+func NewExpiringMap[K comparable, V any](ttl time.Duration, opts ...Option) *ExpiringMap[K, V] {
+	var opt option
+	for _, o := range opts {
+		o(&opt)
+	}
+	if opt.now == nil {
+		opt.now = time.Now
+	}
+	return &ExpiringMap[K, V]{
+		m:   *NewMap[K, expiringEntry[V]](opts...),
+		ttl: ttl,
+		now: opt.now,
+	}
+}
+
+// OnExpire sets a callback called with every entry removed because it expired.
+//
+// It is called without holding the lock, so it may access the map.
+func (e *ExpiringMap[K, V]) OnExpire(fn func(key K, value V)) {
+	e.mu.Lock()
+	e.onExpire = fn
+	e.mu.Unlock()
+}
+
+// Set sets key with the default TTL. Like Map.Set, an existing key keeps its position.
+func (e *ExpiringMap[K, V]) Set(key K, value V) {
+	e.SetWithTTL(key, value, e.ttl)
+}
+
+// SetWithTTL sets key with the given TTL, a ttl <= 0 means it never expires.
+func (e *ExpiringMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	entry := expiringEntry[V]{value: value}
+	if ttl > 0 {
+		entry.expires = e.now().Add(ttl)
+	}
+	e.mu.Lock()
+	e.m.Set(key, entry)
+	e.mu.Unlock()
+}
+
+// Get returns the value of key if present and not expired.
+// An expired entry is removed and reported to the OnExpire callback.
+func (e *ExpiringMap[K, V]) Get(key K) (V, bool) {
+	e.mu.Lock()
+	entry, ok := e.m.TryGet(key)
+	if !ok {
+		e.mu.Unlock()
+		var zero V
+		return zero, false
+	}
+	if !e.expired(entry, e.now()) {
+		e.mu.Unlock()
+		return entry.value, true
+	}
+	e.m.Del(key)
+	onExpire := e.onExpire
+	e.mu.Unlock()
+
+	if onExpire != nil {
+		onExpire(key, entry.value)
+	}
+	var zero V
+	return zero, false
+}
+
+func (e *ExpiringMap[K, V]) Contains(key K) bool {
+	_, ok := e.Get(key)
+	return ok
+}
+
+// TTL returns the remaining time to live of key.
+// It returns 0 and true for an entry that never expires.
+func (e *ExpiringMap[K, V]) TTL(key K) (time.Duration, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.m.TryGet(key)
+	if !ok {
+		return 0, false
+	}
+	if entry.expires.IsZero() {
+		return 0, true
+	}
+	remaining := entry.expires.Sub(e.now())
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// Del removes key without calling the OnExpire callback.
+func (e *ExpiringMap[K, V]) Del(key K) {
+	e.mu.Lock()
+	e.m.Del(key)
+	e.mu.Unlock()
+}
+
+// Len returns the number of entries,
+// including the expired ones that have not been removed yet.
+func (e *ExpiringMap[K, V]) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.m.Len()
+}
+
+// Purge removes all expired entries and returns how many were removed.
+func (e *ExpiringMap[K, V]) Purge() int {
+	e.mu.Lock()
+	now := e.now()
+	var expired []keyValue[K, V]
+	for k, entry := range e.m.Iter {
+		if e.expired(entry, now) {
+			expired = append(expired, keyValue[K, V]{k, entry.value})
+		}
+	}
+	for _, entry := range expired {
+		e.m.Del(entry.key)
+	}
+	onExpire := e.onExpire
+	e.mu.Unlock()
+
+	if onExpire != nil {
+		for _, entry := range expired {
+			onExpire(entry.key, entry.value)
+		}
+	}
+	return len(expired)
+}
+
+// StartJanitor starts a goroutine that calls Purge every interval until ctx is done.
+// The returned channel is closed once the goroutine has returned, and right away
+// if interval <= 0, in which case no janitor is started.
+func (e *ExpiringMap[K, V]) StartJanitor(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	if interval <= 0 {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.Purge()
+			}
+		}
+	}()
+	return done
+}
+
+// Iter yields the entries that are not expired in insertion order.
+//
+// It iterates over a snapshot, so yield may access the map.
+func (e *ExpiringMap[K, V]) Iter(yield func(key K, value V) bool) {
+	e.mu.Lock()
+	now := e.now()
+	live := make([]keyValue[K, V], 0, e.m.Len())
+	for k, entry := range e.m.Iter {
+		if !e.expired(entry, now) {
+			live = append(live, keyValue[K, V]{k, entry.value})
+		}
+	}
+	e.mu.Unlock()
+
+	for _, kv := range live {
+		if !yield(kv.key, kv.value) {
+			return
+		}
+	}
+}
+
+// Keys returns the keys that are not expired in insertion order.
+func (e *ExpiringMap[K, V]) Keys() []K {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	keys := make([]K, 0, e.m.Len())
+	for k, entry := range e.m.Iter {
+		if !e.expired(entry, now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Clear removes all entries without calling the OnExpire callback.
+func (e *ExpiringMap[K, V]) Clear() {
+	e.mu.Lock()
+	e.m.Clear()
+	e.mu.Unlock()
+}
+
+func (e *ExpiringMap[K, V]) expired(entry expiringEntry[V], now time.Time) bool {
+	return !entry.expires.IsZero() && !now.Before(entry.expires)
+}
@@ -0,0 +1,166 @@
+package ordered
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock for deterministic expiry.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestExpiringMap(t *testing.T) {
+	clock := newFakeClock()
+	em := NewExpiringMap[string, int](time.Minute, WithClock(clock.Now))
+
+	var expired []string
+	em.OnExpire(func(key string, _ int) {
+		expired = append(expired, key)
+	})
+
+	em.Set("a", 1)
+	em.SetWithTTL("b", 2, 2*time.Minute)
+	em.SetWithTTL("c", 3, 0) // never expires
+	em.Set("d", 4)
+
+	ttl, ok := em.TTL("b")
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, ttl)
+	ttl, ok = em.TTL("c")
+	require.True(t, ok)
+	require.Zero(t, ttl)
+
+	clock.Advance(30 * time.Second)
+	em.Set("d", 40) // refreshes the TTL
+	require.Equal(t, []string{"a", "b", "c", "d"}, em.Keys())
+
+	clock.Advance(30 * time.Second)
+
+	// lazily expired on Get
+	_, ok = em.Get("a")
+	require.False(t, ok)
+	require.Equal(t, []string{"a"}, expired)
+	require.Equal(t, 3, em.Len())
+
+	v, ok := em.Get("d")
+	require.True(t, ok)
+	require.Equal(t, 40, v)
+
+	clock.Advance(time.Hour)
+	require.Equal(t, []string{"c"}, em.Keys(), "expired entries should be hidden before purging")
+	require.Equal(t, 3, em.Len())
+
+	require.Equal(t, 2, em.Purge())
+	require.Equal(t, []string{"a", "b", "d"}, expired)
+	require.Equal(t, 1, em.Len())
+	require.True(t, em.Contains("c"))
+
+	// Del does not call OnExpire
+	em.Del("c")
+	require.Equal(t, 0, em.Len())
+	require.Len(t, expired, 3)
+}
+
+func TestExpiringMap_Iter(t *testing.T) {
+	clock := newFakeClock()
+	em := NewExpiringMap[string, int](time.Minute, WithClock(clock.Now))
+	em.Set("a", 1)
+	em.SetWithTTL("b", 2, time.Hour)
+	em.Set("c", 3)
+	clock.Advance(time.Minute)
+
+	var keys []string
+	for k, v := range em.Iter {
+		keys = append(keys, k)
+		require.Equal(t, 2, v)
+		em.Set("x", 0) // yield can access the map
+	}
+	require.Equal(t, []string{"b"}, keys)
+}
+
+func TestExpiringMap_OnExpireReentrant(t *testing.T) {
+	clock := newFakeClock()
+	em := NewExpiringMap[string, int](time.Second, WithClock(clock.Now))
+	em.OnExpire(func(key string, value int) {
+		// the callback can access the map without deadlocking
+		em.SetWithTTL(key+"'", value, 0)
+	})
+	em.Set("a", 1)
+	clock.Advance(time.Second)
+
+	require.Equal(t, 1, em.Purge())
+	v, ok := em.Get("a'")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+}
+
+func TestExpiringMap_Janitor(t *testing.T) {
+	clock := newFakeClock()
+	em := NewExpiringMap[int, int](time.Second, WithClock(clock.Now))
+
+	expired := make(chan int, 10)
+	em.OnExpire(func(key int, _ int) {
+		expired <- key
+	})
+	for i := range 3 {
+		em.Set(i, i)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := em.StartJanitor(ctx, time.Millisecond)
+
+	clock.Advance(time.Second)
+	var keys []int
+	for range 3 {
+		select {
+		case key := <-expired:
+			keys = append(keys, key)
+		case <-time.After(time.Second):
+			require.FailNow(t, "janitor did not purge", "expired %v", keys)
+		}
+	}
+	require.ElementsMatch(t, []int{0, 1, 2}, keys)
+	require.Zero(t, em.Len())
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "janitor did not stop")
+	}
+	em.Set(10, 10)
+	clock.Advance(time.Second)
+	require.Equal(t, 1, em.Len(), "stopped janitor must not purge")
+
+	// a non-positive interval starts no janitor
+	for _, interval := range []time.Duration{0, -time.Second} {
+		select {
+		case <-em.StartJanitor(t.Context(), interval):
+		default:
+			require.FailNow(t, "janitor started", "interval %v", interval)
+		}
+	}
+	require.Equal(t, 1, em.Len())
+}
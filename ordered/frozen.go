@@ -0,0 +1,215 @@
+package ordered
+
+import (
+	"iter"
+	"sync"
+)
+
+// Frozen is an immutable ordered map.
+//
+// With and Without return new versions that share most of their structure
+// with the original: keys are indexed by a hash array mapped trie and the
+// entries are kept in order in a persistent vector, so both are O(log32 n).
+// Deleted entries leave holes that are compacted, without sharing, once
+// they make up half of the entries.
+//
+// Frozen is safe for concurrent use. The zero value is an empty map.
+type Frozen[K comparable, V any] struct {
+	index   *hamtNode[K, int]   // key to position in entries
+	entries vector[entry[K, V]] // ordered entries, may contain holes
+	holes   int
+
+	// src is the map a version made by Freeze shares its storage with.
+	// Such versions are read through src until With or Without builds
+	// index and entries from it.
+	src   *Map[K, V]
+	build sync.Once
+
+	thawed *Map[K, V] // built by the first Thaw of other versions
+	thaw   sync.Once
+}
+
+func NewFrozen[K comparable, V any]() *Frozen[K, V] {
+	return &Frozen[K, V]{}
+}
+
+// Freeze returns an immutable copy of the map in O(1).
+//
+// Like Snapshot, the frozen map shares the storage of o until o is modified,
+// and Freeze counts as a write to o. Reads and Thaw go through the shared
+// storage, the structure used by With and Without is built by the first of
+// them, in O(n).
+func (o *Map[K, V]) Freeze() *Frozen[K, V] {
+	return &Frozen[K, V]{src: o.Snapshot()}
+}
+
+func frozenOf[K comparable, V any](n int, seq iter.Seq2[K, V]) *Frozen[K, V] {
+	if n == 0 {
+		return &Frozen[K, V]{}
+	}
+	index := &hamtNode[K, int]{}
+	entries := make([]entry[K, V], 0, n)
+	for k, v := range seq {
+		index.put(hashKey(k), k, len(entries), 0)
+		entries = append(entries, entry[K, V]{key: k, value: v})
+	}
+	return &Frozen[K, V]{
+		index:   index,
+		entries: vectorOf(entries),
+	}
+}
+
+// persistent returns f with index and entries built.
+func (f *Frozen[K, V]) persistent() *Frozen[K, V] {
+	if f.src != nil {
+		f.build.Do(func() {
+			g := frozenOf(f.src.Len(), f.src.Iter)
+			f.index, f.entries = g.index, g.entries
+		})
+	}
+	return f
+}
+
+// Thaw returns a mutable copy of f.
+//
+// The copy shares its storage with f until it is modified, like Map.Snapshot.
+// It is O(1) for maps made by Freeze, otherwise the storage is built by the
+// first Thaw of each version, in O(n).
+func (f *Frozen[K, V]) Thaw() *Map[K, V] {
+	if f.src != nil {
+		return f.src.share()
+	}
+	f.thaw.Do(func() {
+		m := NewMap[K, V](WithCapacity(f.Len()))
+		for k, v := range f.Iter {
+			m.index[k] = len(m.entries)
+			m.entries = append(m.entries, entry[K, V]{key: k, value: v})
+		}
+		m.shared = true
+		f.thawed = m
+	})
+	return f.thawed.share()
+}
+
+func (f *Frozen[K, V]) Len() int {
+	if f.src != nil {
+		return f.src.Len()
+	}
+	return f.entries.len - f.holes
+}
+
+func (f *Frozen[K, V]) Get(key K) V {
+	v, _ := f.TryGet(key)
+	return v
+}
+
+func (f *Frozen[K, V]) TryGet(key K) (V, bool) {
+	if f.src != nil {
+		return f.src.TryGet(key)
+	}
+	if i, ok := f.index.get(hashKey(key), key); ok {
+		return f.entries.get(i).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (f *Frozen[K, V]) Contains(key K) bool {
+	if f.src != nil {
+		return f.src.Contains(key)
+	}
+	_, ok := f.index.get(hashKey(key), key)
+	return ok
+}
+
+// With returns a version of f with key set to value.
+// Like Map.Set, a new key is appended and an existing key keeps its position.
+func (f *Frozen[K, V]) With(key K, value V) *Frozen[K, V] {
+	f = f.persistent()
+	hash := hashKey(key)
+	e := entry[K, V]{key: key, value: value}
+	if i, ok := f.index.get(hash, key); ok {
+		return &Frozen[K, V]{
+			index:   f.index,
+			entries: f.entries.set(i, e),
+			holes:   f.holes,
+		}
+	}
+	index, _ := f.index.with(hash, key, f.entries.len, 0)
+	return &Frozen[K, V]{
+		index:   index,
+		entries: f.entries.push(e),
+		holes:   f.holes,
+	}
+}
+
+// Without returns a version of f without key, or f itself if key is not present.
+func (f *Frozen[K, V]) Without(key K) *Frozen[K, V] {
+	if !f.Contains(key) {
+		return f
+	}
+	if f.Len() == 1 {
+		return &Frozen[K, V]{}
+	}
+	f = f.persistent()
+	hash := hashKey(key)
+	i, _ := f.index.get(hash, key)
+	index, _ := f.index.without(hash, key, 0)
+	g := &Frozen[K, V]{
+		index:   index,
+		entries: f.entries.set(i, entry[K, V]{deleted: true}),
+		holes:   f.holes + 1,
+	}
+	if g.holes*2 > g.entries.len {
+		return frozenOf(g.Len(), g.Iter)
+	}
+	return g
+}
+
+func (f *Frozen[K, V]) Iter(yield func(key K, value V) bool) {
+	if f.src != nil {
+		f.src.Iter(yield)
+		return
+	}
+	f.entries.all(func(e entry[K, V]) bool {
+		return e.deleted || yield(e.key, e.value)
+	})
+}
+
+func (f *Frozen[K, V]) All() iter.Seq2[K, V] {
+	return f.Iter
+}
+
+func (f *Frozen[K, V]) Backward() iter.Seq2[K, V] {
+	if f.src != nil {
+		return f.src.Backward()
+	}
+	return func(yield func(K, V) bool) {
+		f.entries.backward(func(e entry[K, V]) bool {
+			return e.deleted || yield(e.key, e.value)
+		})
+	}
+}
+
+func (f *Frozen[K, V]) Keys() []K {
+	keys := make([]K, 0, f.Len())
+	for k := range f.Iter {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (f *Frozen[K, V]) Values() []V {
+	values := make([]V, 0, f.Len())
+	for _, v := range f.Iter {
+		values = append(values, v)
+	}
+	return values
+}
+
+func (f *Frozen[K, V]) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return appendJSON(f)
+}
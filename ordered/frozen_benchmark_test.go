@@ -0,0 +1,57 @@
+package ordered
+
+import (
+	"strconv"
+	"testing"
+)
+
+// BenchmarkFrozenWith compares deriving a new version of a map
+// with Frozen.With against Map.Clone followed by Map.Set.
+func BenchmarkFrozenWith(b *testing.B) {
+	const size = 10000
+
+	om := NewMap[string, int](WithCapacity(size))
+	for i := range size {
+		om.Set("key"+strconv.Itoa(i), i)
+	}
+	frozen := om.Freeze()
+
+	b.Run("frozen", func(b *testing.B) {
+		i := 0
+		for b.Loop() {
+			_ = frozen.With("key"+strconv.Itoa(i%(size*2)), i)
+			i++
+		}
+	})
+
+	b.Run("clone", func(b *testing.B) {
+		i := 0
+		for b.Loop() {
+			clone := om.Clone()
+			clone.Set("key"+strconv.Itoa(i%(size*2)), i)
+			i++
+		}
+	})
+}
+
+func BenchmarkFreeze(b *testing.B) {
+	const size = 10000
+
+	om := NewMap[string, int](WithCapacity(size))
+	for i := range size {
+		om.Set("key"+strconv.Itoa(i), i)
+	}
+
+	b.Run("freeze", func(b *testing.B) {
+		for b.Loop() {
+			_ = om.Freeze()
+		}
+	})
+
+	b.Run("thaw", func(b *testing.B) {
+		frozen := om.Freeze()
+		for b.Loop() {
+			_ = frozen.Thaw()
+		}
+	})
+}
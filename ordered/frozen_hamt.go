@@ -0,0 +1,232 @@
+package ordered
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"slices"
+)
+
+// hamt is a persistent hash array mapped trie.
+//
+// Every node has up to 32 slots selected by 5 bits of the key hash,
+// only the used ones are stored, compressed with a bitmap.
+// Once the 64 bits of the hash are exhausted, colliding keys are
+// stored in a plain list node.
+
+const (
+	hamtBits     = 5
+	hamtMask     = 1<<hamtBits - 1
+	hamtMaxShift = 64
+)
+
+var hamtSeed = maphash.MakeSeed()
+
+func hashKey[K comparable](key K) uint64 {
+	return maphash.Comparable(hamtSeed, key)
+}
+
+type hamtNode[K comparable, V any] struct {
+	bitmap  uint32
+	entries []hamtEntry[K, V] // a list of colliding keys when bitmap is unused
+}
+
+// hamtEntry is either a sub node or a key-value pair.
+type hamtEntry[K comparable, V any] struct {
+	node  *hamtNode[K, V]
+	hash  uint64
+	key   K
+	value V
+}
+
+func hamtSlot(hash uint64, shift uint) (bit uint32) {
+	return 1 << ((hash >> shift) & hamtMask)
+}
+
+func (n *hamtNode[K, V]) pos(bit uint32) int {
+	return bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[K, V]) get(hash uint64, key K) (V, bool) {
+	for shift := uint(0); n != nil; shift += hamtBits {
+		if shift >= hamtMaxShift {
+			for i := range n.entries {
+				if n.entries[i].key == key {
+					return n.entries[i].value, true
+				}
+			}
+			break
+		}
+		bit := hamtSlot(hash, shift)
+		if n.bitmap&bit == 0 {
+			break
+		}
+		e := &n.entries[n.pos(bit)]
+		if e.node == nil {
+			if e.key == key {
+				return e.value, true
+			}
+			break
+		}
+		n = e.node
+	}
+	var zero V
+	return zero, false
+}
+
+// with returns a copy of n with key set to value, sharing the untouched nodes.
+// added reports whether key is new.
+func (n *hamtNode[K, V]) with(hash uint64, key K, value V, shift uint) (_ *hamtNode[K, V], added bool) {
+	leaf := hamtEntry[K, V]{hash: hash, key: key, value: value}
+	if n == nil {
+		n = &hamtNode[K, V]{}
+	}
+
+	if shift >= hamtMaxShift {
+		for i := range n.entries {
+			if n.entries[i].key == key {
+				c := &hamtNode[K, V]{entries: slices.Clone(n.entries)}
+				c.entries[i] = leaf
+				return c, false
+			}
+		}
+		return &hamtNode[K, V]{entries: append(slices.Clip(n.entries), leaf)}, true
+	}
+
+	bit := hamtSlot(hash, shift)
+	i := n.pos(bit)
+	if n.bitmap&bit == 0 {
+		entries := make([]hamtEntry[K, V], 0, len(n.entries)+1)
+		entries = append(entries, n.entries[:i]...)
+		entries = append(entries, leaf)
+		entries = append(entries, n.entries[i:]...)
+		return &hamtNode[K, V]{bitmap: n.bitmap | bit, entries: entries}, true
+	}
+
+	c := &hamtNode[K, V]{bitmap: n.bitmap, entries: slices.Clone(n.entries)}
+	e := &c.entries[i]
+	switch {
+	case e.node != nil:
+		e.node, added = e.node.with(hash, key, value, shift+hamtBits)
+	case e.key == key:
+		*e = leaf
+	default:
+		*e = hamtEntry[K, V]{node: hamtPair(*e, leaf, shift+hamtBits)}
+		added = true
+	}
+	return c, added
+}
+
+// put sets key to value in place, it must only be called on nodes that are not shared.
+func (n *hamtNode[K, V]) put(hash uint64, key K, value V, shift uint) {
+	leaf := hamtEntry[K, V]{hash: hash, key: key, value: value}
+	for ; ; shift += hamtBits {
+		if shift >= hamtMaxShift {
+			for i := range n.entries {
+				if n.entries[i].key == key {
+					n.entries[i] = leaf
+					return
+				}
+			}
+			n.entries = append(n.entries, leaf)
+			return
+		}
+
+		bit := hamtSlot(hash, shift)
+		i := n.pos(bit)
+		if n.bitmap&bit == 0 {
+			n.bitmap |= bit
+			n.entries = slices.Insert(n.entries, i, leaf)
+			return
+		}
+		e := &n.entries[i]
+		switch {
+		case e.node != nil:
+			n = e.node
+		case e.key == key:
+			*e = leaf
+			return
+		default:
+			*e = hamtEntry[K, V]{node: hamtPair(*e, leaf, shift+hamtBits)}
+			return
+		}
+	}
+}
+
+// hamtPair returns a node holding two leaves with different keys.
+func hamtPair[K comparable, V any](a, b hamtEntry[K, V], shift uint) *hamtNode[K, V] {
+	if shift >= hamtMaxShift {
+		return &hamtNode[K, V]{entries: []hamtEntry[K, V]{a, b}}
+	}
+	bitA, bitB := hamtSlot(a.hash, shift), hamtSlot(b.hash, shift)
+	switch {
+	case bitA == bitB:
+		return &hamtNode[K, V]{bitmap: bitA, entries: []hamtEntry[K, V]{{node: hamtPair(a, b, shift+hamtBits)}}}
+	case bitA < bitB:
+		return &hamtNode[K, V]{bitmap: bitA | bitB, entries: []hamtEntry[K, V]{a, b}}
+	default:
+		return &hamtNode[K, V]{bitmap: bitA | bitB, entries: []hamtEntry[K, V]{b, a}}
+	}
+}
+
+// without returns a copy of n without key, sharing the untouched nodes.
+// It returns nil if the resulting node is empty.
+func (n *hamtNode[K, V]) without(hash uint64, key K, shift uint) (_ *hamtNode[K, V], removed bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	if shift >= hamtMaxShift {
+		i := slices.IndexFunc(n.entries, func(e hamtEntry[K, V]) bool {
+			return e.key == key
+		})
+		if i == -1 {
+			return n, false
+		}
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		return &hamtNode[K, V]{entries: slices.Delete(slices.Clone(n.entries), i, i+1)}, true
+	}
+
+	bit := hamtSlot(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	i := n.pos(bit)
+	e := n.entries[i]
+	if e.node == nil {
+		if e.key != key {
+			return n, false
+		}
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		return &hamtNode[K, V]{
+			bitmap:  n.bitmap &^ bit,
+			entries: slices.Delete(slices.Clone(n.entries), i, i+1),
+		}, true
+	}
+
+	child, removed := e.node.without(hash, key, shift+hamtBits)
+	if !removed {
+		return n, false
+	}
+	switch {
+	case child == nil:
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		return &hamtNode[K, V]{
+			bitmap:  n.bitmap &^ bit,
+			entries: slices.Delete(slices.Clone(n.entries), i, i+1),
+		}, true
+	case len(child.entries) == 1 && child.entries[0].node == nil:
+		// lift a lone leaf up
+		e = child.entries[0]
+	default:
+		e = hamtEntry[K, V]{node: child}
+	}
+	c := &hamtNode[K, V]{bitmap: n.bitmap, entries: slices.Clone(n.entries)}
+	c.entries[i] = e
+	return c, true
+}
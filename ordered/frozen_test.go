@@ -0,0 +1,226 @@
+package ordered
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrozen(t *testing.T) {
+	var empty Frozen[string, int]
+	require.Equal(t, 0, empty.Len())
+	require.False(t, empty.Contains("a"))
+
+	v1 := empty.With("b", 2).With("a", 1)
+	v2 := v1.With("c", 3).With("b", 20)
+	v3 := v2.Without("a")
+	v4 := v3.Without("nonexistent")
+
+	require.Equal(t, 0, empty.Len())
+	require.Equal(t, []string{"b", "a"}, v1.Keys())
+	require.Equal(t, []int{2, 1}, v1.Values())
+	require.Equal(t, []string{"b", "a", "c"}, v2.Keys())
+	require.Equal(t, []int{20, 1, 3}, v2.Values())
+	require.Equal(t, []string{"b", "c"}, v3.Keys())
+	require.Same(t, v3, v4)
+
+	require.Equal(t, 20, v3.Get("b"))
+	_, ok := v3.TryGet("a")
+	require.False(t, ok)
+	require.True(t, v2.Contains("a"))
+
+	var backward []string
+	for k := range v2.Backward() {
+		backward = append(backward, k)
+	}
+	require.Equal(t, []string{"c", "a", "b"}, backward)
+
+	data, err := json.Marshal(v2)
+	require.NoError(t, err)
+	require.Equal(t, `{"b":20,"a":1,"c":3}`, string(data))
+
+	require.Equal(t, 0, v1.Without("a").Without("b").Len())
+}
+
+func TestFrozen_FreezeThaw(t *testing.T) {
+	om := newPositionTestMap("c", "a", "d", "b")
+	om.Del("d")
+
+	f := om.Freeze()
+	require.Equal(t, []string{"c", "a", "b"}, f.Keys())
+
+	// the frozen map is independent of the original
+	om.Set("x", 10)
+	om.Del("c")
+	require.Equal(t, []string{"c", "a", "b"}, f.Keys())
+
+	thawed := f.Thaw()
+	require.Equal(t, []string{"c", "a", "b"}, thawed.Keys())
+	require.Equal(t, []int{0, 1, 3}, thawed.Values())
+	thawed.Set("y", 1)
+	require.Equal(t, 3, f.Len())
+	require.Equal(t, 2, thawed.IndexOf("b"))
+
+	// versions of a frozen map are independent of it and of each other
+	g := f.With("a", 10).With("z", 26)
+	h := f.Without("c")
+	require.Equal(t, []string{"c", "a", "b", "z"}, g.Keys())
+	require.Equal(t, []int{0, 10, 3, 26}, g.Values())
+	require.Equal(t, []string{"a", "b"}, h.Keys())
+	require.Equal(t, []int{0, 1, 3}, f.Values())
+	require.Same(t, f, f.Without("x"))
+
+	// thawing a version twice gives independent maps
+	t1, t2 := g.Thaw(), g.Thaw()
+	t1.Del("c")
+	t2.Set("c", -1)
+	require.Equal(t, []string{"a", "b", "z"}, t1.Keys())
+	require.Equal(t, []int{-1, 10, 3, 26}, t2.Values())
+	require.Equal(t, []int{0, 10, 3, 26}, g.Values())
+
+	var backward []string
+	for k := range f.Backward() {
+		backward = append(backward, k)
+	}
+	require.Equal(t, []string{"b", "a", "c"}, backward)
+	data, err := json.Marshal(f)
+	require.NoError(t, err)
+	require.Equal(t, `{"c":0,"a":1,"b":3}`, string(data))
+}
+
+// TestFrozen_Model checks random operations against a Map,
+// while making sure older versions never change.
+func TestFrozen_Model(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	type version struct {
+		frozen *Frozen[int, int]
+		keys   []int
+		values []int
+	}
+
+	model := NewMap[int, int]()
+	frozen := NewFrozen[int, int]()
+	var versions []version
+
+	for i := range 20000 {
+		key := rng.IntN(2000)
+		if rng.IntN(3) == 0 {
+			model.Del(key)
+			frozen = frozen.Without(key)
+		} else {
+			model.Set(key, i)
+			frozen = frozen.With(key, i)
+		}
+		if i%1000 == 0 {
+			versions = append(versions, version{frozen, model.Keys(), model.Values()})
+		}
+	}
+
+	require.Equal(t, model.Len(), frozen.Len())
+	require.Equal(t, model.Keys(), frozen.Keys())
+	require.Equal(t, model.Values(), frozen.Values())
+	for k := range 2000 {
+		want, wantOK := model.TryGet(k)
+		got, gotOK := frozen.TryGet(k)
+		require.Equal(t, wantOK, gotOK)
+		require.Equal(t, want, got)
+	}
+
+	for _, v := range versions {
+		require.Equal(t, v.keys, v.frozen.Keys())
+		require.Equal(t, v.values, v.frozen.Values())
+	}
+}
+
+func TestFrozen_LargeFreeze(t *testing.T) {
+	const size = 5000
+	om := NewMap[string, int]()
+	for i := range size {
+		om.Set("key"+strconv.Itoa(i), i)
+	}
+	f := om.Freeze()
+	require.Equal(t, size, f.Len())
+	for i := range size {
+		require.Equal(t, i, f.Get("key"+strconv.Itoa(i)))
+	}
+	f = f.With("new", -1)
+	require.Equal(t, "new", f.Keys()[size])
+	require.Equal(t, om.Keys(), f.Without("new").Keys())
+}
+
+func TestHAMT_Collisions(t *testing.T) {
+	// force every key to the same hash
+	const hash = 0xdeadbeef
+	var n *hamtNode[string, int]
+	var versions []*hamtNode[string, int]
+	for i := range 10 {
+		n, _ = n.with(hash, strconv.Itoa(i), i, 0)
+		versions = append(versions, n)
+	}
+	for i := range 10 {
+		v, ok := n.get(hash, strconv.Itoa(i))
+		require.True(t, ok)
+		require.Equal(t, i, v)
+	}
+
+	n, added := n.with(hash, "5", 50, 0)
+	require.False(t, added)
+	v, _ := n.get(hash, "5")
+	require.Equal(t, 50, v)
+	v, _ = versions[9].get(hash, "5")
+	require.Equal(t, 5, v)
+
+	for i := range 10 {
+		var removed bool
+		n, removed = n.without(hash, strconv.Itoa(i), 0)
+		require.True(t, removed)
+		_, ok := n.get(hash, strconv.Itoa(i))
+		require.False(t, ok)
+	}
+	require.Nil(t, n)
+
+	// a partial collision splits on the last bits
+	n, _ = n.with(0, "a", 1, 0)
+	n, _ = n.with(1<<63, "b", 2, 0)
+	n.put(1<<63|1, "c", 3, 0)
+	for k, want := range map[string]int{"a": 1, "b": 2, "c": 3} {
+		got, ok := n.get(map[string]uint64{"a": 0, "b": 1 << 63, "c": 1<<63 | 1}[k], k)
+		require.True(t, ok)
+		require.Equal(t, want, got)
+	}
+}
+
+func TestVector(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 32, 33, 1024, 1025, 40000} {
+		values := make([]int, size)
+		for i := range values {
+			values[i] = i
+		}
+
+		built := vectorOf(values)
+		var pushed vector[int]
+		for _, v := range values {
+			pushed = pushed.push(v)
+		}
+		for _, v := range []vector[int]{built, pushed} {
+			require.Equal(t, size, v.len)
+			require.Equal(t, values, append([]int{}, slices.Collect(v.all)...))
+			for i := range size {
+				require.Equal(t, i, v.get(i))
+			}
+		}
+
+		if size > 0 {
+			updated := built.set(size-1, -1)
+			require.Equal(t, -1, updated.get(size-1))
+			require.Equal(t, size-1, built.get(size-1))
+			require.Equal(t, -1, slices.Collect(updated.backward)[0])
+			require.Equal(t, size-1, slices.Collect(built.backward)[0])
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package ordered
+
+import (
+	"slices"
+)
+
+// vector is a persistent vector, a 32-ary trie indexed by position
+// in which every update copies only the path to the changed leaf.
+
+const (
+	vecBits  = 5
+	vecWidth = 1 << vecBits
+	vecMask  = vecWidth - 1
+)
+
+type vecNode[T any] struct {
+	children []*vecNode[T] // set for internal nodes
+	values   []T           // set for leaves
+}
+
+type vector[T any] struct {
+	root  *vecNode[T]
+	shift uint // vecBits times the height of root, 0 when root is a leaf
+	len   int
+}
+
+// vectorOf builds a vector holding values without sharing its backing array.
+func vectorOf[T any](values []T) vector[T] {
+	if len(values) == 0 {
+		return vector[T]{}
+	}
+
+	level := make([]*vecNode[T], 0, (len(values)+vecMask)/vecWidth)
+	for chunk := range slices.Chunk(values, vecWidth) {
+		level = append(level, &vecNode[T]{values: slices.Clone(chunk)})
+	}
+
+	shift := uint(0)
+	for len(level) > 1 {
+		parents := make([]*vecNode[T], 0, (len(level)+vecMask)/vecWidth)
+		for chunk := range slices.Chunk(level, vecWidth) {
+			parents = append(parents, &vecNode[T]{children: chunk})
+		}
+		level = parents
+		shift += vecBits
+	}
+	return vector[T]{root: level[0], shift: shift, len: len(values)}
+}
+
+func (v vector[T]) get(i int) T {
+	n := v.root
+	for shift := v.shift; shift > 0; shift -= vecBits {
+		n = n.children[(i>>shift)&vecMask]
+	}
+	return n.values[i&vecMask]
+}
+
+// set returns a copy of v with the value at position i replaced.
+func (v vector[T]) set(i int, value T) vector[T] {
+	v.root = v.root.set(v.shift, i, value)
+	return v
+}
+
+func (n *vecNode[T]) set(shift uint, i int, value T) *vecNode[T] {
+	if shift == 0 {
+		c := &vecNode[T]{values: slices.Clone(n.values)}
+		c.values[i&vecMask] = value
+		return c
+	}
+	c := &vecNode[T]{children: slices.Clone(n.children)}
+	j := (i >> shift) & vecMask
+	c.children[j] = n.children[j].set(shift-vecBits, i, value)
+	return c
+}
+
+// push returns a copy of v with value appended.
+func (v vector[T]) push(value T) vector[T] {
+	switch {
+	case v.root == nil:
+		v.root = &vecNode[T]{values: []T{value}}
+	case v.len == 1<<(v.shift+vecBits): // root is full
+		v.root = &vecNode[T]{children: []*vecNode[T]{v.root, vecPath(v.shift, value)}}
+		v.shift += vecBits
+	default:
+		v.root = v.root.push(v.shift, v.len, value)
+	}
+	v.len++
+	return v
+}
+
+func (n *vecNode[T]) push(shift uint, i int, value T) *vecNode[T] {
+	if shift == 0 {
+		// clip so that append never writes to the shared backing array
+		return &vecNode[T]{values: append(slices.Clip(n.values), value)}
+	}
+	j := (i >> shift) & vecMask
+	if j == len(n.children) {
+		return &vecNode[T]{children: append(slices.Clip(n.children), vecPath(shift-vecBits, value))}
+	}
+	c := &vecNode[T]{children: slices.Clone(n.children)}
+	c.children[j] = n.children[j].push(shift-vecBits, i, value)
+	return c
+}
+
+// vecPath returns a new branch of the given height holding a single value.
+func vecPath[T any](shift uint, value T) *vecNode[T] {
+	if shift == 0 {
+		return &vecNode[T]{values: []T{value}}
+	}
+	return &vecNode[T]{children: []*vecNode[T]{vecPath(shift-vecBits, value)}}
+}
+
+// all yields the values in order.
+func (v vector[T]) all(yield func(T) bool) {
+	if v.root != nil {
+		v.root.all(yield)
+	}
+}
+
+func (n *vecNode[T]) all(yield func(T) bool) bool {
+	for _, value := range n.values {
+		if !yield(value) {
+			return false
+		}
+	}
+	for _, child := range n.children {
+		if !child.all(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// backward yields the values in reverse order.
+func (v vector[T]) backward(yield func(T) bool) {
+	if v.root != nil {
+		v.root.backward(yield)
+	}
+}
+
+func (n *vecNode[T]) backward(yield func(T) bool) bool {
+	for _, value := range slices.Backward(n.values) {
+		if !yield(value) {
+			return false
+		}
+	}
+	for _, child := range slices.Backward(n.children) {
+		if !child.backward(yield) {
+			return false
+		}
+	}
+	return true
+}
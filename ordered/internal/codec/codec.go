@@ -0,0 +1,126 @@
+// Package codec walks Go values for the self-describing binary formats of
+// ordered/msgpack and ordered/cbor, which only read and write the primitives.
+//
+// Ordered containers are found by reflection: pointers to types of the ordered
+// packages with an Iter method are encoded as maps when Iter yields key-value
+// pairs and as arrays when it yields single elements, in iteration order.
+// They are decoded with their Set, Add or With method.
+//
+// The package is also used by the binary encoding of package ordered,
+// so it cannot import it: ordered provides the maps decoded into interfaces
+// through NewStringMap and NewAnyMap.
+package codec
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDepth is the maximum nesting of encoded and decoded values,
+// it also stops the encoding of cyclic values.
+const maxDepth = 10000
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// orderedPkgPath is the import path of package ordered, its subpackages have it as a prefix.
+const orderedPkgPath = "github.com/yusing/ds/ordered"
+
+// NewStringMap and NewAnyMap return an empty *ordered.Map[string, any] with
+// WithNestedMaps, and an empty *ordered.Map[any, any], with their Set method.
+// They are set by package ordered.
+var (
+	NewStringMap func(capacity int) (m any, set func(key string, value any))
+	NewAnyMap    func(capacity int) (m any, set func(key, value any))
+)
+
+// isOrdered reports whether t is an ordered container or a pointer to one.
+func isOrdered(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || !strings.HasPrefix(t.PkgPath(), orderedPkgPath) {
+		return false
+	}
+	iter, ok := reflect.PointerTo(t).MethodByName("Iter")
+	if !ok {
+		return false
+	}
+	// func(receiver, yield) where yield is func(K, V) bool or func(T) bool
+	if iter.Type.NumIn() != 2 || iter.Type.NumOut() != 0 {
+		return false
+	}
+	yield := iter.Type.In(1)
+	return yield.Kind() == reflect.Func && yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool &&
+		(yield.NumIn() == 1 || yield.NumIn() == 2)
+}
+
+// field is an exported struct field.
+type field struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+type fieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+var fieldsCache sync.Map // fieldsKey -> []field
+
+// structFields returns the exported fields of t, named by the tag key if set.
+func structFields(t reflect.Type, tag string) []field {
+	key := fieldsKey{t, tag}
+	if fields, ok := fieldsCache.Load(key); ok {
+		return fields.([]field)
+	}
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		f := field{name: sf.Name, index: i}
+		if tv, ok := sf.Tag.Lookup(tag); ok {
+			if tv == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tv, ",")
+			if name != "" {
+				f.name = name
+			}
+			f.omitEmpty = opts == "omitempty"
+		}
+		fields = append(fields, f)
+	}
+	fieldsCache.Store(key, fields)
+	return fields
+}
+
+// isEmpty reports whether v is empty for omitempty, like encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// UnmarshalTypeError describes a value that cannot be decoded into a Go type.
+type UnmarshalTypeError struct {
+	Format string       // format name, like "msgpack"
+	Value  string       // description of the encoded value, like "string" or "array"
+	Type   reflect.Type // type of the Go value it could not be assigned to
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("%s: cannot unmarshal %s into Go value of type %s", e.Format, e.Value, e.Type)
+}
@@ -0,0 +1,530 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Kind is the kind of a Token.
+type Kind uint8
+
+const (
+	KindNil Kind = iota
+	KindBool
+	KindInt  // negative integers
+	KindUint // non-negative integers
+	KindFloat
+	KindString
+	KindBytes
+	KindTime
+	KindArray
+	KindMap
+	KindType // the dynamic type of the interface value that follows, see TypeWriter
+)
+
+var kindNames = [...]string{
+	KindNil:    "nil",
+	KindBool:   "bool",
+	KindInt:    "integer",
+	KindUint:   "integer",
+	KindFloat:  "float",
+	KindString: "string",
+	KindBytes:  "bytes",
+	KindTime:   "time",
+	KindArray:  "array",
+	KindMap:    "map",
+	KindType:   "type",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Token is a primitive read by a Reader.
+type Token struct {
+	Kind  Kind
+	Bool  bool
+	Int   int64
+	Uint  uint64
+	Float float64
+	Bytes []byte // KindString and KindBytes, only valid until the next call to the Reader
+	Time  time.Time
+	Len   int // KindArray and KindMap, -1 if the length is indefinite
+	Type  reflect.Type
+}
+
+// Reader reads the primitives of a format.
+//
+// Arrays and maps are followed by their elements, and by their key-value pairs
+// one after the other. A Reader must return an error if the length of an array
+// or map is larger than the bytes left, so that it can be used as a capacity.
+type Reader interface {
+	Next() (Token, error)
+	// Break reports whether the end of the innermost container of indefinite length
+	// is next, and skips over it.
+	Break() (bool, error)
+}
+
+// Decode reads the next value from r into v, which must be a non-nil pointer.
+// Format is used in the error messages.
+//
+// Like encoding/json, nil sets pointers, interfaces, maps and slices to nil and
+// is a no-op for other values, maps and ordered containers are added to and
+// decoding into an interface stores nil, bool, int64 (uint64 if it overflows),
+// float64, string, []byte, time.Time, []any and maps as
+// *ordered.Map[string, any], or *ordered.Map[any, any] if any key is not a string.
+func Decode(r Reader, v any, format string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%s: Unmarshal(non-pointer or nil %T)", format, v)
+	}
+	d := decoder{r: r, format: format}
+	return d.decodeNext(rv.Elem(), 0)
+}
+
+type decoder struct {
+	r      Reader
+	format string
+}
+
+func (d *decoder) decodeNext(v reflect.Value, depth int) error {
+	tok, err := d.r.Next()
+	if err != nil {
+		return err
+	}
+	return d.decode(v, tok, depth)
+}
+
+// each calls fn for each of the n elements of a container, n < 0 means indefinite.
+func (d *decoder) each(n int, fn func(i int) error) error {
+	for i := 0; n < 0 || i < n; i++ {
+		if n < 0 {
+			end, err := d.r.Break()
+			if err != nil {
+				return err
+			}
+			if end {
+				return nil
+			}
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) typeError(tok Token, t reflect.Type) error {
+	return &UnmarshalTypeError{Format: d.format, Value: tok.Kind.String(), Type: t}
+}
+
+func (d *decoder) decode(v reflect.Value, tok Token, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%s: exceeded max depth of %d", d.format, maxDepth)
+	}
+	t := v.Type()
+	if tok.Kind == KindType {
+		// only interfaces keep the type, other values are decoded as is
+		if v.Kind() != reflect.Interface || !tok.Type.Implements(t) {
+			return d.decodeNext(v, depth+1)
+		}
+		x := reflect.New(tok.Type).Elem()
+		if err := d.decodeNext(x, depth+1); err != nil {
+			return err
+		}
+		v.Set(x)
+		return nil
+	}
+	if tok.Kind == KindNil {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			v.SetZero()
+		}
+		return nil
+	}
+
+	switch {
+	case isOrdered(t):
+		if t.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(t.Elem()))
+			}
+			return d.decodeOrdered(v, tok, depth)
+		}
+		return d.decodeOrdered(v.Addr(), tok, depth)
+	case t.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return d.decode(v.Elem(), tok, depth+1)
+	case t == timeType:
+		switch tok.Kind {
+		case KindTime:
+			v.Set(reflect.ValueOf(tok.Time))
+		case KindString:
+			tm, err := time.Parse(time.RFC3339Nano, string(tok.Bytes))
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(tm))
+		default:
+			return d.typeError(tok, t)
+		}
+		return nil
+	case tok.Kind == KindString && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(tok.Bytes)
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError(tok, t)
+		}
+		x, err := d.decodeAny(tok, depth)
+		if err != nil {
+			return err
+		}
+		if x == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(x))
+		}
+	case reflect.Bool:
+		if tok.Kind != KindBool {
+			return d.typeError(tok, t)
+		}
+		v.SetBool(tok.Bool)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch tok.Kind {
+		case KindInt:
+			n = tok.Int
+		case KindUint:
+			if tok.Uint > math.MaxInt64 {
+				return d.overflowError(tok, t)
+			}
+			n = int64(tok.Uint)
+		default:
+			return d.typeError(tok, t)
+		}
+		if v.OverflowInt(n) {
+			return d.overflowError(tok, t)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if tok.Kind == KindInt {
+			return d.overflowError(tok, t)
+		}
+		if tok.Kind != KindUint {
+			return d.typeError(tok, t)
+		}
+		if v.OverflowUint(tok.Uint) {
+			return d.overflowError(tok, t)
+		}
+		v.SetUint(tok.Uint)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch tok.Kind {
+		case KindFloat:
+			f = tok.Float
+		case KindInt:
+			f = float64(tok.Int)
+		case KindUint:
+			f = float64(tok.Uint)
+		default:
+			return d.typeError(tok, t)
+		}
+		if v.OverflowFloat(f) {
+			return d.overflowError(tok, t)
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		if tok.Kind != KindString && tok.Kind != KindBytes {
+			return d.typeError(tok, t)
+		}
+		v.SetString(string(tok.Bytes))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && (tok.Kind == KindBytes || tok.Kind == KindString) {
+			v.SetBytes(bytes.Clone(tok.Bytes))
+			return nil
+		}
+		if tok.Kind != KindArray {
+			return d.typeError(tok, t)
+		}
+		s := reflect.MakeSlice(t, max(tok.Len, 0), max(tok.Len, 0))
+		err := d.each(tok.Len, func(i int) error {
+			if i >= s.Len() {
+				s = reflect.Append(s, reflect.Zero(t.Elem()))
+			}
+			return d.decodeNext(s.Index(i), depth+1)
+		})
+		if err != nil {
+			return err
+		}
+		v.Set(s)
+	case reflect.Array:
+		if tok.Kind != KindArray {
+			return d.typeError(tok, t)
+		}
+		n := 0
+		err := d.each(tok.Len, func(i int) error {
+			n++
+			if i >= v.Len() { // the extra elements are dropped
+				return d.skip(depth + 1)
+			}
+			return d.decodeNext(v.Index(i), depth+1)
+		})
+		if err != nil {
+			return err
+		}
+		for i := n; i < v.Len(); i++ {
+			v.Index(i).SetZero()
+		}
+	case reflect.Map:
+		if tok.Kind != KindMap {
+			return d.typeError(tok, t)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(t, max(tok.Len, 0)))
+		}
+		return d.each(tok.Len, func(int) error {
+			key := reflect.New(t.Key()).Elem()
+			if err := d.decodeNext(key, depth+1); err != nil {
+				return err
+			}
+			if key.Kind() == reflect.Interface && !key.IsNil() && !key.Elem().Type().Comparable() {
+				return &UnmarshalTypeError{Format: d.format, Value: "map key of type " + key.Elem().Type().String(), Type: t}
+			}
+			value := reflect.New(t.Elem()).Elem()
+			if err := d.decodeNext(value, depth+1); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+			return nil
+		})
+	case reflect.Struct:
+		if tok.Kind != KindMap {
+			return d.typeError(tok, t)
+		}
+		fields := structFields(t, d.format)
+		return d.each(tok.Len, func(int) error {
+			keyTok, err := d.r.Next()
+			if err != nil {
+				return err
+			}
+			if keyTok.Kind != KindString {
+				return &UnmarshalTypeError{Format: d.format, Value: keyTok.Kind.String() + " key", Type: t}
+			}
+			if f, ok := findField(fields, string(keyTok.Bytes)); ok {
+				return d.decodeNext(v.Field(f.index), depth+1)
+			}
+			return d.skip(depth + 1)
+		})
+	default:
+		return d.typeError(tok, t)
+	}
+	return nil
+}
+
+// findField returns the field named name, or else the one named name case-insensitively.
+func findField(fields []field, name string) (field, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func (d *decoder) overflowError(tok Token, t reflect.Type) error {
+	var s string
+	if tok.Kind == KindInt {
+		s = fmt.Sprint(tok.Int)
+	} else {
+		s = fmt.Sprint(tok.Uint)
+	}
+	return &UnmarshalTypeError{Format: d.format, Value: "integer " + s, Type: t}
+}
+
+// decodeOrdered decodes into the ordered container pointed to by p.
+func (d *decoder) decodeOrdered(p reflect.Value, tok Token, depth int) error {
+	t := p.Type()
+	iter := p.MethodByName("Iter")
+	if iter.Type().In(0).NumIn() == 1 { // set-like
+		if tok.Kind != KindArray {
+			return d.typeError(tok, t)
+		}
+		add := p.MethodByName("Add")
+		if !add.IsValid() || add.Type().NumIn() != 1 {
+			return fmt.Errorf("%s: cannot unmarshal into %s", d.format, t)
+		}
+		return d.each(tok.Len, func(int) error {
+			elem := reflect.New(add.Type().In(0)).Elem()
+			if err := d.decodeNext(elem, depth+1); err != nil {
+				return err
+			}
+			add.Call([]reflect.Value{elem})
+			return nil
+		})
+	}
+
+	if tok.Kind != KindMap {
+		return d.typeError(tok, t)
+	}
+	set := p.MethodByName("Set")
+	if !set.IsValid() {
+		set = p.MethodByName("Add")
+	}
+	with := p.MethodByName("With") // immutable maps return a new version
+	insert := set
+	if !insert.IsValid() {
+		insert = with
+	}
+	if !insert.IsValid() || insert.Type().NumIn() != 2 {
+		return fmt.Errorf("%s: cannot unmarshal into %s", d.format, t)
+	}
+	return d.each(tok.Len, func(int) error {
+		key := reflect.New(insert.Type().In(0)).Elem()
+		if err := d.decodeNext(key, depth+1); err != nil {
+			return err
+		}
+		if key.Kind() == reflect.Interface && !key.IsNil() && !key.Elem().Type().Comparable() {
+			return &UnmarshalTypeError{Format: d.format, Value: "map key of type " + key.Elem().Type().String(), Type: t}
+		}
+		value := reflect.New(insert.Type().In(1)).Elem()
+		if err := d.decodeNext(value, depth+1); err != nil {
+			return err
+		}
+		out := insert.Call([]reflect.Value{key, value})
+		switch {
+		case !set.IsValid(): // With
+			p.Elem().Set(out[0].Elem())
+		case len(out) == 1 && out[0].Type() == errorType && !out[0].IsNil():
+			return out[0].Interface().(error)
+		}
+		return nil
+	})
+}
+
+var errorType = reflect.TypeFor[error]()
+
+// decodeAny decodes tok into the natural Go value of its kind.
+func (d *decoder) decodeAny(tok Token, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%s: exceeded max depth of %d", d.format, maxDepth)
+	}
+	switch tok.Kind {
+	case KindNil:
+		return nil, nil
+	case KindBool:
+		return tok.Bool, nil
+	case KindInt:
+		return tok.Int, nil
+	case KindUint:
+		if tok.Uint > math.MaxInt64 {
+			return tok.Uint, nil
+		}
+		return int64(tok.Uint), nil
+	case KindFloat:
+		return tok.Float, nil
+	case KindString:
+		return string(tok.Bytes), nil
+	case KindBytes:
+		return bytes.Clone(tok.Bytes), nil
+	case KindTime:
+		return tok.Time, nil
+	case KindType:
+		x := reflect.New(tok.Type).Elem()
+		if err := d.decodeNext(x, depth+1); err != nil {
+			return nil, err
+		}
+		return x.Interface(), nil
+	case KindArray:
+		arr := make([]any, 0, max(tok.Len, 0))
+		err := d.each(tok.Len, func(int) error {
+			x, err := d.decodeAnyNext(depth + 1)
+			arr = append(arr, x)
+			return err
+		})
+		return arr, err
+	default: // KindMap
+		var keys, values []any
+		allStrings := true
+		err := d.each(tok.Len, func(int) error {
+			key, err := d.decodeAnyNext(depth + 1)
+			if err != nil {
+				return err
+			}
+			if _, ok := key.(string); !ok {
+				allStrings = false
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					return &UnmarshalTypeError{Format: d.format, Value: fmt.Sprintf("map key of type %T", key), Type: reflect.TypeFor[any]()}
+				}
+			}
+			value, err := d.decodeAnyNext(depth + 1)
+			keys = append(keys, key)
+			values = append(values, value)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if allStrings {
+			m, set := NewStringMap(len(keys))
+			for i, key := range keys {
+				set(key.(string), values[i])
+			}
+			return m, nil
+		}
+		m, set := NewAnyMap(len(keys))
+		for i, key := range keys {
+			set(key, values[i])
+		}
+		return m, nil
+	}
+}
+
+func (d *decoder) decodeAnyNext(depth int) (any, error) {
+	tok, err := d.r.Next()
+	if err != nil {
+		return nil, err
+	}
+	return d.decodeAny(tok, depth)
+}
+
+// skip reads over the next value.
+func (d *decoder) skip(depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%s: exceeded max depth of %d", d.format, maxDepth)
+	}
+	tok, err := d.r.Next()
+	if err != nil {
+		return err
+	}
+	switch tok.Kind {
+	case KindType:
+		return d.skip(depth + 1)
+	case KindArray:
+		return d.each(tok.Len, func(int) error {
+			return d.skip(depth + 1)
+		})
+	case KindMap:
+		return d.each(tok.Len, func(int) error {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+			return d.skip(depth + 1)
+		})
+	}
+	return nil
+}
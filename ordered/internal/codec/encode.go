@@ -0,0 +1,217 @@
+package codec
+
+import (
+	"cmp"
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Writer writes the primitives of a format.
+type Writer interface {
+	Nil()
+	Bool(b bool)
+	Int(i int64)
+	Uint(u uint64)
+	Float32(f float32)
+	Float64(f float64)
+	String(s string)
+	Bytes(b []byte)
+	Time(t time.Time)
+	ArrayHeader(n int)
+	MapHeader(n int)
+}
+
+// TypeWriter is implemented by the Writers of formats that record the dynamic
+// type of interface values. Type writes the tag of t, which precedes the value,
+// and reports whether t has one. Values of other types are written as is.
+type TypeWriter interface {
+	Type(t reflect.Type) bool
+}
+
+// Encode writes v to w, format is used in the error messages.
+//
+// Go maps are written with sorted keys when the keys are strings or numbers,
+// structs as maps of their exported fields named by the format tag,
+// and types implementing encoding.TextMarshaler as strings.
+func Encode(w Writer, v any, format string) error {
+	e := encoder{w: w, format: format}
+	return e.encode(reflect.ValueOf(v), 0)
+}
+
+type encoder struct {
+	w      Writer
+	format string
+}
+
+func (e *encoder) encode(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%s: exceeded max depth of %d, the value may be cyclic", e.format, maxDepth)
+	}
+	if !v.IsValid() {
+		e.w.Nil()
+		return nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == timeType:
+		e.w.Time(v.Interface().(time.Time))
+		return nil
+	case isOrdered(t):
+		return e.encodeOrdered(v, depth)
+	case t.Implements(textMarshalerType):
+		if t.Kind() == reflect.Pointer && v.IsNil() {
+			e.w.Nil()
+			return nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.w.String(string(text))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.w.Nil()
+			return nil
+		}
+		if tw, ok := e.w.(TypeWriter); ok && v.Kind() == reflect.Interface {
+			tw.Type(v.Elem().Type())
+		}
+		return e.encode(v.Elem(), depth+1)
+	case reflect.Bool:
+		e.w.Bool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.w.Int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.w.Uint(v.Uint())
+	case reflect.Float32:
+		e.w.Float32(float32(v.Float()))
+	case reflect.Float64:
+		e.w.Float64(v.Float())
+	case reflect.String:
+		e.w.String(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.w.Nil()
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			e.w.Bytes(v.Bytes())
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		e.w.ArrayHeader(v.Len())
+		for i := range v.Len() {
+			if err := e.encode(v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.w.Nil()
+			return nil
+		}
+		keys := v.MapKeys()
+		sortKeys(keys)
+		e.w.MapHeader(len(keys))
+		for _, key := range keys {
+			if err := e.encode(key, depth+1); err != nil {
+				return err
+			}
+			if err := e.encode(v.MapIndex(key), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return e.encodeStruct(v, depth)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", e.format, t)
+	}
+	return nil
+}
+
+// encodeOrdered writes an ordered container in iteration order.
+func (e *encoder) encodeOrdered(v reflect.Value, depth int) error {
+	switch {
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			e.w.Nil()
+			return nil
+		}
+	case v.CanAddr():
+		v = v.Addr()
+	default:
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+
+	// collect first, the length may differ from the number of yielded items (e.g. ExpiringMap)
+	iter := v.MethodByName("Iter")
+	yieldType := iter.Type().In(0)
+	var items []reflect.Value
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		items = append(items, args...)
+		return []reflect.Value{reflect.ValueOf(true)}
+	})
+	iter.Call([]reflect.Value{yield})
+
+	if yieldType.NumIn() == 1 {
+		e.w.ArrayHeader(len(items))
+	} else {
+		e.w.MapHeader(len(items) / 2)
+	}
+	for _, item := range items {
+		if err := e.encode(item, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeStruct(v reflect.Value, depth int) error {
+	fields := structFields(v.Type(), e.format)
+	n := 0
+	for _, f := range fields {
+		if !f.omitEmpty || !isEmpty(v.Field(f.index)) {
+			n++
+		}
+	}
+	e.w.MapHeader(n)
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		if f.omitEmpty && isEmpty(fv) {
+			continue
+		}
+		e.w.String(f.name)
+		if err := e.encode(fv, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortKeys sorts map keys of string or number kinds so that the output is deterministic.
+func sortKeys(keys []reflect.Value) {
+	if len(keys) < 2 {
+		return
+	}
+	switch keys[0].Kind() {
+	case reflect.String:
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(a.String(), b.String()) })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(a.Int(), b.Int()) })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(a.Uint(), b.Uint()) })
+	case reflect.Float32, reflect.Float64:
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return cmp.Compare(a.Float(), b.Float()) })
+	}
+}
@@ -0,0 +1,482 @@
+package ordered
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JSONPatch is a JSON patch (RFC 6902) operating on nested ordered maps,
+// see ParseJSONPatch and JSONPatch.Apply.
+type JSONPatch []JSONPatchOp
+
+// JSONPatchOp is a single operation of a JSONPatch.
+type JSONPatchOp struct {
+	Op    string `json:"op"` // add, remove, replace, move, copy or test
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`  // for move and copy
+	Value any    `json:"value,omitempty"` // for add, replace and test, where it is always written
+}
+
+// MarshalJSON writes the operation, with its value for add, replace and test even if it is nil.
+func (op JSONPatchOp) MarshalJSON() ([]byte, error) {
+	type plain JSONPatchOp // without the MarshalJSON method
+	switch op.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			plain
+			Value any `json:"value"`
+		}{plain(op), op.Value})
+	}
+	return json.Marshal(plain(op))
+}
+
+var (
+	ErrInvalidJSONPatch = errors.New("invalid json patch")
+	ErrPathNotFound     = errors.New("path not found")
+	ErrInvalidPath      = errors.New("invalid path")
+	ErrTestFailed       = errors.New("test failed")
+)
+
+// JSONPatchError is returned by JSONPatch.Apply when an operation fails.
+type JSONPatchError struct {
+	Index int    // index of the operation in the patch
+	Op    string // the operation
+	Path  string // the path, or from, the error is about
+	Err   error
+}
+
+func (e *JSONPatchError) Error() string {
+	return fmt.Sprintf("json patch operation %d (%s %q): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *JSONPatchError) Unwrap() error {
+	return e.Err
+}
+
+// ParseJSONPatch decodes a JSON patch document.
+//
+// Objects in the values are decoded into *Map[string, any] like with WithNestedMaps.
+func ParseJSONPatch(data []byte) (JSONPatch, error) {
+	var raw []struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	patch := make(JSONPatch, len(raw))
+	for i, r := range raw {
+		op := JSONPatchOp{Op: r.Op}
+		fail := func(format string, args ...any) error {
+			return &JSONPatchError{Index: i, Op: r.Op, Path: op.Path, Err: fmt.Errorf("%w: "+format, append([]any{ErrInvalidJSONPatch}, args...)...)}
+		}
+		if r.Path == nil {
+			return nil, fail("missing path")
+		}
+		op.Path = *r.Path
+
+		switch r.Op {
+		case "add", "replace", "test":
+			if r.Value == nil {
+				return nil, fail("missing value")
+			}
+			v, err := decodeNested(r.Value)
+			if err != nil {
+				return nil, err
+			}
+			op.Value = v
+		case "move", "copy":
+			if r.From == nil {
+				return nil, fail("missing from")
+			}
+			op.From = *r.From
+		case "remove":
+		default:
+			return nil, fail("unknown operation %q", r.Op)
+		}
+		patch[i] = op
+	}
+	return patch, nil
+}
+
+// ApplyJSONPatch parses patch and applies it to doc, see JSONPatch.Apply.
+func ApplyJSONPatch(doc *Map[string, any], patch []byte) error {
+	p, err := ParseJSONPatch(patch)
+	if err != nil {
+		return err
+	}
+	return p.Apply(doc)
+}
+
+// Apply applies the operations in order to doc.
+//
+// Objects must be *Map[string, any] and arrays []any. Added keys are appended
+// and replaced keys keep their position. The operations are atomic: if one
+// fails, Apply returns a *JSONPatchError and doc is left unchanged.
+//
+// The operations are first applied to copies of the maps along their paths, leaving
+// doc and its storage untouched. Once they all succeed, they are applied again to doc
+// and its nested maps in place, like MergePatch, so their observers are notified and
+// references to nested maps stay valid. Replacing the whole document clears doc
+// and sets the entries of the new value.
+func (p JSONPatch) Apply(doc *Map[string, any]) error {
+	if err := p.apply(doc, patchCopies{}); err != nil {
+		return err
+	}
+	return p.apply(doc, nil)
+}
+
+// patchCopies holds the maps cloned by a dry run of a patch, which it modifies
+// directly, so each map of the document is cloned at most once.
+// A nil patchCopies applies the patch in place.
+type patchCopies map[*Map[string, any]]struct{}
+
+func (p JSONPatch) apply(doc *Map[string, any], copies patchCopies) error {
+	var root any = doc
+	for i, op := range p {
+		var err error
+		root, err = op.apply(root, copies)
+		if err != nil {
+			var pe *JSONPatchError
+			if errors.As(err, &pe) {
+				pe.Index, pe.Op = i, op.Op
+				return pe
+			}
+			return &JSONPatchError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+
+	result, ok := root.(*Map[string, any])
+	if !ok {
+		return &JSONPatchError{Index: len(p) - 1, Op: p[len(p)-1].Op, Err: fmt.Errorf("%w: the document must remain an object, got %s", ErrInvalidPath, describeValue(root))}
+	}
+	if copies == nil && result != doc {
+		doc.Clear()
+		for k, v := range result.Iter {
+			doc.Set(k, v)
+		}
+	}
+	return nil
+}
+
+// apply applies op to root and returns the new root. The maps along the path
+// are modified in place if copies is nil, otherwise replaced by copies.
+func (op JSONPatchOp) apply(root any, copies patchCopies) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		value := deepCloneAny[string](op.Value)
+		return pointerUpdate(root, path, 0, func(parent any, path []string) (any, error) {
+			return pointerAdd(parent, path, value, copies)
+		}, value, copies)
+	case "remove":
+		if len(path) == 0 {
+			return nil, &JSONPatchError{Path: op.Path, Err: fmt.Errorf("%w: cannot remove the document", ErrInvalidPath)}
+		}
+		return pointerUpdate(root, path, 0, func(parent any, path []string) (any, error) {
+			return pointerRemove(parent, path, copies)
+		}, nil, copies)
+	case "replace":
+		if _, err := pointerGet(root, path); err != nil {
+			return nil, err
+		}
+		value := deepCloneAny[string](op.Value)
+		return pointerUpdate(root, path, 0, func(parent any, path []string) (any, error) {
+			return pointerReplace(parent, path, value, copies)
+		}, value, copies)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, &JSONPatchError{Path: op.From, Err: err}
+		}
+		value, err := pointerGet(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			value = deepCloneAny[string](value)
+		} else {
+			if slices.Equal(from, path) {
+				return root, nil
+			}
+			if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+				return nil, &JSONPatchError{Path: op.Path, Err: fmt.Errorf("%w: cannot move %q into itself", ErrInvalidPath, op.From)}
+			}
+			root, err = JSONPatchOp{Op: "remove", Path: op.From}.apply(root, copies)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return JSONPatchOp{Op: "add", Path: op.Path, Value: value}.apply(root, copies)
+	case "test":
+		value, err := pointerGet(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(value, op.Value) {
+			return nil, &JSONPatchError{Path: op.Path, Err: ErrTestFailed}
+		}
+		return root, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidJSONPatch, op.Op)
+	}
+}
+
+// parsePointer splits a JSON pointer (RFC 6901) into unescaped reference tokens.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("%w: %q must start with /", ErrInvalidPath, s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, tok := range tokens {
+		if !strings.Contains(tok, "~") {
+			continue
+		}
+		for j := 0; j < len(tok); j++ {
+			if tok[j] == '~' && (j+1 == len(tok) || (tok[j+1] != '0' && tok[j+1] != '1')) {
+				return nil, fmt.Errorf("%w: invalid escape in %q", ErrInvalidPath, s)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// formatPointer is the inverse of parsePointer.
+func formatPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+func pointerGet(root any, path []string) (any, error) {
+	v := root
+	for i, key := range path {
+		var err error
+		v, err = pointerChild(v, key, path[:i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func pointerChild(v any, key string, prefix []string) (any, error) {
+	switch v := v.(type) {
+	case *Map[string, any]:
+		child, ok := v.TryGet(key)
+		if !ok {
+			return nil, pointerError(prefix, ErrPathNotFound)
+		}
+		return child, nil
+	case []any:
+		i, err := arrayIndex(key, len(v), prefix)
+		if err != nil {
+			return nil, err
+		}
+		return v[i], nil
+	default:
+		return nil, pointerError(prefix, fmt.Errorf("%w: %s has no members", ErrPathNotFound, describeValue(v)))
+	}
+}
+
+// pointerUpdate calls fn with the parent of path[depth:] in v and path,
+// and returns a copy of v with the containers along path replaced by the results.
+// If copies is nil, the maps are modified rather than copied and keep their identity.
+// The value of an empty path, the whole document, is replaced by value.
+func pointerUpdate(v any, path []string, depth int, fn func(parent any, path []string) (any, error), value any, copies patchCopies) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	if depth == len(path)-1 {
+		return fn(v, path)
+	}
+
+	child, err := pointerChild(v, path[depth], path[:depth+1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = pointerUpdate(child, path, depth+1, fn, value, copies)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := child.(*Map[string, any]); ok && copies == nil {
+		return v, nil // modified in place
+	}
+	switch v := v.(type) {
+	case *Map[string, any]:
+		m := mutableMap(v, copies)
+		m.Set(path[depth], child)
+		return m, nil
+	default: // []any, checked by pointerChild
+		arr := slices.Clone(v.([]any))
+		i, _ := strconv.Atoi(path[depth])
+		arr[i] = child
+		return arr, nil
+	}
+}
+
+func pointerAdd(parent any, path []string, value any, copies patchCopies) (any, error) {
+	key := path[len(path)-1]
+	switch parent := parent.(type) {
+	case *Map[string, any]:
+		m := mutableMap(parent, copies)
+		m.Set(key, value)
+		return m, nil
+	case []any:
+		i := len(parent)
+		if key != "-" {
+			var err error
+			if i, err = arrayIndex(key, len(parent)+1, path); err != nil {
+				return nil, err
+			}
+		}
+		return slices.Insert(slices.Clip(parent), i, value), nil
+	default:
+		return nil, pointerError(path[:len(path)-1], fmt.Errorf("%w: cannot add to %s", ErrInvalidPath, describeValue(parent)))
+	}
+}
+
+func pointerRemove(parent any, path []string, copies patchCopies) (any, error) {
+	key := path[len(path)-1]
+	switch parent := parent.(type) {
+	case *Map[string, any]:
+		if !parent.Contains(key) {
+			return nil, pointerError(path, ErrPathNotFound)
+		}
+		m := mutableMap(parent, copies)
+		m.Del(key)
+		return m, nil
+	case []any:
+		i, err := arrayIndex(key, len(parent), path)
+		if err != nil {
+			return nil, err
+		}
+		return slices.Delete(slices.Clone(parent), i, i+1), nil
+	default:
+		return nil, pointerError(path, fmt.Errorf("%w: %s has no members", ErrPathNotFound, describeValue(parent)))
+	}
+}
+
+func pointerReplace(parent any, path []string, value any, copies patchCopies) (any, error) {
+	key := path[len(path)-1]
+	switch parent := parent.(type) {
+	case *Map[string, any]:
+		m := mutableMap(parent, copies)
+		m.Set(key, value)
+		return m, nil
+	default: // []any, checked by pointerGet
+		arr := slices.Clone(parent.([]any))
+		i, _ := strconv.Atoi(key)
+		arr[i] = value
+		return arr, nil
+	}
+}
+
+// mutableMap returns m if copies is nil or holds m, otherwise a copy of m added to copies.
+func mutableMap(m *Map[string, any], copies patchCopies) *Map[string, any] {
+	if copies == nil {
+		return m
+	}
+	if _, ok := copies[m]; ok {
+		return m
+	}
+	c := m.Clone()
+	copies[c] = struct{}{}
+	return c
+}
+
+// arrayIndex parses an array index in [0, n).
+func arrayIndex(key string, n int, path []string) (int, error) {
+	if key == "-" {
+		return 0, pointerError(path, fmt.Errorf("%w: index - refers to a nonexistent element", ErrPathNotFound))
+	}
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || key[0] == '+' || (key[0] == '0' && len(key) > 1) {
+		return 0, pointerError(path, fmt.Errorf("%w: invalid array index %q", ErrInvalidPath, key))
+	}
+	if i >= n {
+		return 0, pointerError(path, fmt.Errorf("%w: index %d out of range with length %d", ErrPathNotFound, i, n))
+	}
+	return i, nil
+}
+
+// pointerError wraps err with the path where it happened, which may be a prefix of the operation path.
+func pointerError(path []string, err error) error {
+	return &JSONPatchError{Path: formatPointer(path), Err: err}
+}
+
+// jsonEqual reports whether a and b are equal JSON values, ignoring the order of object keys.
+// Numbers are equal if they have the same value, whatever their type (RFC 6902, section 4.6).
+func jsonEqual(a, b any) bool {
+	switch a := a.(type) {
+	case *Map[string, any]:
+		b, ok := b.(*Map[string, any])
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for k, av := range a.Iter {
+			bv, ok := b.TryGet(k)
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, jsonEqual)
+	default:
+		if x, ok := jsonNumber(a); ok {
+			y, ok := jsonNumber(b)
+			return ok && x.Cmp(y) == 0
+		}
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// jsonNumber returns the exact value of a Go or json.Number number, ok is false for other values and NaN.
+func jsonNumber(v any) (f *big.Float, ok bool) {
+	if n, isNumber := v.(json.Number); isNumber {
+		if i, isInt := new(big.Int).SetString(string(n), 10); isInt {
+			return new(big.Float).SetInt(i), true
+		}
+		x, err := n.Float64()
+		if err != nil {
+			return nil, false
+		}
+		v = x
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Float).SetUint64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(rv.Float()) {
+			return nil, false
+		}
+		return new(big.Float).SetFloat64(rv.Float()), true
+	}
+	return nil, false
+}
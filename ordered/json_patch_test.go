@@ -0,0 +1,293 @@
+package ordered
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newNestedTestMap(t *testing.T, data string) *Map[string, any] {
+	t.Helper()
+	om := NewMap[string, any](WithNestedMaps())
+	require.NoError(t, json.Unmarshal([]byte(data), om))
+	return om
+}
+
+func requireJSON(t *testing.T, want string, om *Map[string, any]) {
+	t.Helper()
+	data, err := json.Marshal(om)
+	require.NoError(t, err)
+	require.Equal(t, want, string(data))
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7396, appendix A
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		// order
+		{`{"z":1,"a":{"y":1,"b":2}}`, `{"n":3,"a":{"c":3,"y":0},"z":2}`, `{"z":2,"a":{"y":0,"b":2,"c":3},"n":3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			om := newNestedTestMap(t, tt.doc)
+			require.NoError(t, MergePatch(om, []byte(tt.patch)))
+			requireJSON(t, tt.want, om)
+		})
+	}
+
+	t.Run("not an object", func(t *testing.T) {
+		om := newNestedTestMap(t, `{"a":1}`)
+		var typeErr *json.UnmarshalTypeError
+		require.ErrorAs(t, MergePatch(om, []byte(`["a"]`)), &typeErr)
+		require.Equal(t, "array", typeErr.Value)
+		require.Error(t, MergePatch(om, []byte(`{"a":2} {}`)))
+		requireJSON(t, `{"a":1}`, om)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		om := newNestedTestMap(t, `{"a":1,"b":2}`)
+		var kinds []EventKind
+		om.Observe(func(e Event[string, any]) { kinds = append(kinds, e.Kind) })
+		require.NoError(t, MergePatch(om, []byte(`{"a":null,"b":3,"c":4}`)))
+		require.Equal(t, []EventKind{EventDeleted, EventUpdated, EventInserted}, kinds)
+	})
+}
+
+func TestJSONPatch(t *testing.T) {
+	// examples from RFC 6902, appendix A, plus order checks
+	tests := []struct {
+		name, doc, patch, want string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"add existing member", `{"a":1,"b":2}`, `[{"op":"add","path":"/a","value":3}]`, `{"a":3,"b":2}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append array element", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{"add nested member", `{"foo":{"x":1}}`, `[{"op":"add","path":"/foo/bar","value":{"z":1,"a":2}}]`, `{"foo":{"x":1,"bar":{"z":1,"a":2}}}`},
+		{"add null", `{}`, `[{"op":"add","path":"/a","value":null}]`, `{"a":null}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"replace document", `{"a":1}`, `[{"op":"replace","path":"","value":{"b":2}}]`, `{"b":2}`},
+		{
+			"move member",
+			`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"move to itself", `{"a":1,"b":2}`, `[{"op":"move","from":"/a","path":"/a"}]`, `{"a":1,"b":2}`},
+		{"copy", `{"a":{"b":1},"c":2}`, `[{"op":"copy","from":"/a","path":"/d"},{"op":"replace","path":"/d/b","value":3}]`, `{"a":{"b":1},"c":2,"d":{"b":3}}`},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"test object ignores order", `{"a":{"x":1,"y":[{"b":1,"c":2}]}}`, `[{"op":"test","path":"/a","value":{"y":[{"c":2,"b":1}],"x":1}}]`, `{"a":{"x":1,"y":[{"b":1,"c":2}]}}`},
+		{"escaped keys", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"replace","path":"/~1","value":0}]`, `{"/":0,"~1":10}`},
+		{"empty key", `{"":1}`, `[{"op":"replace","path":"/","value":2}]`, `{"":2}`},
+		{"empty patch", `{"a":1}`, `[]`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			om := newNestedTestMap(t, tt.doc)
+			require.NoError(t, ApplyJSONPatch(om, []byte(tt.patch)))
+			requireJSON(t, tt.want, om)
+		})
+	}
+}
+
+func TestJSONPatch_Errors(t *testing.T) {
+	const doc = `{"a":{"b":[1,2]},"c":"d"}`
+	tests := []struct {
+		name, patch string
+		index       int
+		path        string
+		err         error
+	}{
+		{"missing parent", `[{"op":"add","path":"/x/y","value":1}]`, 0, "/x", ErrPathNotFound},
+		{"missing nested parent", `[{"op":"add","path":"/a/x/y","value":1}]`, 0, "/a/x", ErrPathNotFound},
+		{"scalar parent", `[{"op":"add","path":"/c/y","value":1}]`, 0, "/c", ErrInvalidPath},
+		{"remove missing", `[{"op":"test","path":"/c","value":"d"},{"op":"remove","path":"/a/z"}]`, 1, "/a/z", ErrPathNotFound},
+		{"remove document", `[{"op":"remove","path":""}]`, 0, "", ErrInvalidPath},
+		{"replace missing", `[{"op":"replace","path":"/a/b/2","value":1}]`, 0, "/a/b/2", ErrPathNotFound},
+		{"array index out of range", `[{"op":"add","path":"/a/b/3","value":1}]`, 0, "/a/b/3", ErrPathNotFound},
+		{"array index leading zero", `[{"op":"remove","path":"/a/b/01"}]`, 0, "/a/b/01", ErrInvalidPath},
+		{"array index not a number", `[{"op":"remove","path":"/a/b/x"}]`, 0, "/a/b/x", ErrInvalidPath},
+		{"array end", `[{"op":"replace","path":"/a/b/-","value":1}]`, 0, "/a/b/-", ErrPathNotFound},
+		{"member of scalar", `[{"op":"test","path":"/c/x","value":1}]`, 0, "/c/x", ErrPathNotFound},
+		{"invalid pointer", `[{"op":"remove","path":"a"}]`, 0, "a", ErrInvalidPath},
+		{"invalid escape", `[{"op":"remove","path":"/a~2"}]`, 0, "/a~2", ErrInvalidPath},
+		{"move missing", `[{"op":"move","from":"/x","path":"/y"}]`, 0, "/x", ErrPathNotFound},
+		{"move into itself", `[{"op":"move","from":"/a","path":"/a/b/0"}]`, 0, "/a/b/0", ErrInvalidPath},
+		{"test failed", `[{"op":"add","path":"/x","value":1},{"op":"test","path":"/a/b","value":[2,1]}]`, 1, "/a/b", ErrTestFailed},
+		{"document not an object", `[{"op":"replace","path":"","value":[1]}]`, 0, "", ErrInvalidPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			om := newNestedTestMap(t, doc)
+			nested := om.Get("a").(*Map[string, any])
+
+			err := ApplyJSONPatch(om, []byte(tt.patch))
+			require.ErrorIs(t, err, tt.err)
+			var pe *JSONPatchError
+			require.ErrorAs(t, err, &pe)
+			require.Equal(t, tt.index, pe.Index)
+			require.Equal(t, tt.path, pe.Path)
+
+			requireJSON(t, doc, om)
+			require.Same(t, nested, om.Get("a"))
+			requireJSON(t, `{"b":[1,2]}`, nested)
+		})
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		for _, patch := range []string{
+			`{}`,
+			`[{"op":"add","value":1}]`,
+			`[{"op":"add","path":"/a"}]`,
+			`[{"op":"move","path":"/a"}]`,
+			`[{"op":"frobnicate","path":"/a"}]`,
+		} {
+			_, err := ParseJSONPatch([]byte(patch))
+			require.Error(t, err, patch)
+		}
+		_, err := ParseJSONPatch([]byte(`[{"op":"frobnicate","path":"/a"}]`))
+		require.ErrorIs(t, err, ErrInvalidJSONPatch)
+	})
+}
+
+func TestJSONPatch_Marshal(t *testing.T) {
+	const patch = `[{"op":"add","path":"/x","value":null},{"op":"replace","path":"/y","value":null},` +
+		`{"op":"test","path":"/x","value":null},{"op":"remove","path":"/y"},{"op":"move","path":"/z","from":"/x"}]`
+	p, err := ParseJSONPatch([]byte(patch))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.JSONEq(t, patch, string(data))
+	p2, err := ParseJSONPatch(data)
+	require.NoError(t, err)
+	require.Equal(t, p, p2)
+
+	om := newNestedTestMap(t, `{"y":1}`)
+	require.NoError(t, p2.Apply(om))
+	requireJSON(t, `{"z":null}`, om)
+}
+
+func TestJSONPatch_Atomic(t *testing.T) {
+	om := newNestedTestMap(t, `{"a":{"b":{"c":1}},"d":[1]}`)
+	b := om.Get("a").(*Map[string, any]).Get("b").(*Map[string, any])
+	d := om.Get("d").([]any)
+
+	patch, err := ParseJSONPatch([]byte(`[
+		{"op":"add","path":"/a/b/x","value":2},
+		{"op":"remove","path":"/a/b/c"},
+		{"op":"add","path":"/d/0","value":0}
+	]`))
+	require.NoError(t, err)
+
+	require.NoError(t, patch.Apply(om))
+	requireJSON(t, `{"a":{"b":{"x":2}},"d":[0,1]}`, om)
+
+	// nested maps are modified in place, arrays are replaced
+	require.Same(t, b, om.Get("a").(*Map[string, any]).Get("b"))
+	requireJSON(t, `{"x":2}`, b)
+	require.Equal(t, []any{float64(1)}, d)
+
+	// values are copied from the patch
+	om.Get("a").(*Map[string, any]).Get("b").(*Map[string, any]).Set("x", 3)
+	require.Equal(t, float64(2), patch[0].Value)
+
+	// the dry run copies the maps rather than sharing their storage
+	require.False(t, om.shared)
+	require.False(t, b.shared)
+	require.False(t, om.Get("a").(*Map[string, any]).shared)
+
+	// the patch can be applied again
+	om2 := newNestedTestMap(t, `{"a":{"b":{"c":1}},"d":[1]}`)
+	require.NoError(t, patch.Apply(om2))
+	requireJSON(t, `{"a":{"b":{"x":2}},"d":[0,1]}`, om2)
+}
+
+func TestJSONPatch_Events(t *testing.T) {
+	om := newNestedTestMap(t, `{"a":{"b":1},"c":2}`)
+	nested := om.Get("a").(*Map[string, any])
+
+	var events []string
+	om.Observe(func(e Event[string, any]) { events = append(events, e.Kind.String()+" "+e.Key) })
+	nested.Observe(func(e Event[string, any]) { events = append(events, e.Kind.String()+" a/"+e.Key) })
+
+	require.NoError(t, ApplyJSONPatch(om, []byte(`[
+		{"op":"replace","path":"/a/b","value":10},
+		{"op":"add","path":"/a/x","value":1},
+		{"op":"move","from":"/c","path":"/d"},
+		{"op":"test","path":"/d","value":2}
+	]`)))
+	require.Equal(t, []string{"updated a/b", "inserted a/x", "deleted c", "inserted d"}, events)
+	require.Same(t, nested, om.Get("a"))
+	requireJSON(t, `{"b":10,"x":1}`, nested)
+	requireJSON(t, `{"a":{"b":10,"x":1},"d":2}`, om)
+
+	// failing patches notify nothing
+	events = nil
+	require.ErrorIs(t, ApplyJSONPatch(om, []byte(`[{"op":"remove","path":"/a/b"},{"op":"test","path":"/d","value":3}]`)), ErrTestFailed)
+	require.Empty(t, events)
+	requireJSON(t, `{"a":{"b":10,"x":1},"d":2}`, om)
+
+	// replacing the document refills doc
+	require.NoError(t, ApplyJSONPatch(om, []byte(`[{"op":"replace","path":"","value":{"z":1}}]`)))
+	require.Equal(t, []string{"cleared ", "inserted z"}, events)
+	requireJSON(t, `{"z":1}`, om)
+}
+
+func TestJSONPatch_TestNumbers(t *testing.T) {
+	om := NewMap[string, any]()
+	om.Set("int", 1)
+	om.Set("int64", int64(-2))
+	om.Set("uint64", uint64(math.MaxUint64))
+	om.Set("float", 1.5)
+	om.Set("number", json.Number("3"))
+
+	equal := []struct {
+		path  string
+		value any
+	}{
+		{"/int", float64(1)},
+		{"/int", json.Number("1.0")},
+		{"/int", uint8(1)},
+		{"/int64", float32(-2)},
+		{"/int64", json.Number("-2")},
+		{"/uint64", json.Number("18446744073709551615")},
+		{"/float", json.Number("1.5")},
+		{"/number", 3},
+	}
+	for _, tt := range equal {
+		p := JSONPatch{{Op: "test", Path: tt.path, Value: tt.value}}
+		require.NoError(t, p.Apply(om), "%s %#v", tt.path, tt.value)
+	}
+
+	different := []struct {
+		path  string
+		value any
+	}{
+		{"/int", 1.5},
+		{"/int", "1"},
+		{"/int", true},
+		{"/uint64", float64(math.MaxUint64)},
+		{"/float", json.Number("x")},
+		{"/number", math.NaN()},
+	}
+	for _, tt := range different {
+		p := JSONPatch{{Op: "test", Path: tt.path, Value: tt.value}}
+		require.ErrorIs(t, p.Apply(om), ErrTestFailed, "%s %#v", tt.path, tt.value)
+	}
+}
@@ -0,0 +1,144 @@
+package ordered
+
+// LRU is a least recently used cache built on Map.
+//
+// Entries are kept from the least to the most recently used,
+// promotion is an amortized O(1) Map.MoveToBack.
+//
+// LRU is not safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	m        Map[K, V]
+	capacity int
+	onEvict  func(key K, value V)
+	stats    LRUStats
+}
+
+// LRUStats holds the cache statistics of an LRU.
+type LRUStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// NewLRU creates an LRU that holds at most the number of entries
+// given by WithCapacity. A capacity <= 0 means unbounded.
+func NewLRU[K comparable, V any](opts ...Option) *LRU[K, V] {
+	var opt option
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &LRU[K, V]{
+		m:        *NewMap[K, V](opts...),
+		capacity: opt.capacity,
+	}
+}
+
+// OnEvict sets a callback called with every entry evicted
+// because the cache is over capacity.
+func (c *LRU[K, V]) OnEvict(fn func(key K, value V)) {
+	c.onEvict = fn
+}
+
+// Get returns the value of key and marks it as the most recently used.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	value, ok := c.m.TryGet(key)
+	if !ok {
+		c.stats.Misses++
+		return value, false
+	}
+	c.stats.Hits++
+	c.m.MoveToBack(key)
+	return value, true
+}
+
+// Peek returns the value of key without updating its recency or the stats.
+func (c *LRU[K, V]) Peek(key K) (V, bool) {
+	return c.m.TryGet(key)
+}
+
+func (c *LRU[K, V]) Contains(key K) bool {
+	return c.m.Contains(key)
+}
+
+// Set adds or updates key as the most recently used entry,
+// evicting the least recently used ones when over capacity.
+func (c *LRU[K, V]) Set(key K, value V) {
+	if c.m.Contains(key) {
+		c.m.Set(key, value)
+		c.m.MoveToBack(key)
+		return
+	}
+	c.m.Set(key, value)
+	c.evict()
+}
+
+// Del removes key without calling the eviction callback.
+// It returns whether key was present.
+func (c *LRU[K, V]) Del(key K) bool {
+	if !c.m.Contains(key) {
+		return false
+	}
+	c.m.Del(key)
+	return true
+}
+
+func (c *LRU[K, V]) Len() int {
+	return c.m.Len()
+}
+
+func (c *LRU[K, V]) Cap() int {
+	return c.capacity
+}
+
+// Resize changes the capacity, evicting the least recently used entries
+// if the cache holds more than the new capacity. A capacity <= 0 means unbounded.
+func (c *LRU[K, V]) Resize(capacity int) {
+	c.capacity = capacity
+	c.evict()
+}
+
+// Oldest returns the least recently used entry without promoting it.
+func (c *LRU[K, V]) Oldest() (key K, value V, ok bool) {
+	if c.m.Len() == 0 {
+		return key, value, false
+	}
+	key, value = c.m.At(0)
+	return key, value, true
+}
+
+// Iter yields the entries from the least to the most recently used
+// without promoting them.
+func (c *LRU[K, V]) Iter(yield func(key K, value V) bool) {
+	c.m.Iter(yield)
+}
+
+func (c *LRU[K, V]) Keys() []K {
+	return c.m.Keys()
+}
+
+func (c *LRU[K, V]) Stats() LRUStats {
+	return c.stats
+}
+
+func (c *LRU[K, V]) ResetStats() {
+	c.stats = LRUStats{}
+}
+
+// Clear removes all entries without calling the eviction callback.
+func (c *LRU[K, V]) Clear() {
+	c.m.Clear()
+}
+
+func (c *LRU[K, V]) evict() {
+	if c.capacity <= 0 {
+		return
+	}
+	for c.m.Len() > c.capacity {
+		key, value := c.m.At(0)
+		c.m.Del(key)
+		c.stats.Evictions++
+		if c.onEvict != nil {
+			c.onEvict(key, value)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ordered
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU[string, int](WithCapacity(3))
+	require.Equal(t, 3, c.Cap())
+
+	var evicted []string
+	c.OnEvict(func(key string, value int) {
+		evicted = append(evicted, key+"="+strconv.Itoa(value))
+	})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	// promote a, b becomes the least recently used
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.Equal(t, []string{"b", "c", "a"}, c.Keys())
+
+	c.Set("d", 4)
+	require.Equal(t, []string{"b=2"}, evicted)
+	require.Equal(t, []string{"c", "a", "d"}, c.Keys())
+
+	// Peek does not promote
+	v, ok = c.Peek("c")
+	require.True(t, ok)
+	require.Equal(t, 3, v)
+	key, _, ok := c.Oldest()
+	require.True(t, ok)
+	require.Equal(t, "c", key)
+
+	// updating promotes without evicting
+	c.Set("c", 30)
+	require.Equal(t, []string{"a", "d", "c"}, c.Keys())
+	require.Len(t, evicted, 1)
+
+	_, ok = c.Get("b")
+	require.False(t, ok)
+
+	require.Equal(t, LRUStats{Hits: 1, Misses: 1, Evictions: 1}, c.Stats())
+	c.ResetStats()
+	require.Equal(t, LRUStats{}, c.Stats())
+
+	require.True(t, c.Del("d"))
+	require.False(t, c.Del("d"))
+	require.Len(t, evicted, 1, "Del should not call OnEvict")
+	require.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Resize(t *testing.T) {
+	c := NewLRU[int, int](WithCapacity(5))
+	var evicted []int
+	c.OnEvict(func(key int, _ int) {
+		evicted = append(evicted, key)
+	})
+	for i := range 5 {
+		c.Set(i, i)
+	}
+	c.Get(0)
+
+	c.Resize(2)
+	require.Equal(t, []int{1, 2, 3}, evicted)
+	require.Equal(t, []int{4, 0}, c.Keys())
+
+	c.Resize(0) // unbounded
+	for i := range 100 {
+		c.Set(i, i)
+	}
+	require.Equal(t, 100, c.Len())
+	require.Len(t, evicted, 3)
+}
+
+func TestLRU_Churn(t *testing.T) {
+	const capacity = 100
+	c := NewLRU[int, int](WithCapacity(capacity))
+
+	for i := range 10000 {
+		c.Set(i, i)
+		// keep the first key hot
+		_, ok := c.Get(0)
+		require.True(t, ok)
+	}
+	require.Equal(t, capacity, c.Len())
+
+	keys := c.Keys()
+	require.Equal(t, 0, keys[len(keys)-1])
+	for i, k := range keys[:len(keys)-1] {
+		require.Equal(t, 10000-capacity+1+i, k)
+	}
+	require.LessOrEqual(t, len(c.m.entries), 2*capacity+1)
+}
+
+func BenchmarkLRU(b *testing.B) {
+	for _, capacity := range []int{1000, 100000} {
+		b.Run(strconv.Itoa(capacity), func(b *testing.B) {
+			c := NewLRU[int, int](WithCapacity(capacity))
+			for i := range capacity {
+				c.Set(i, i)
+			}
+
+			i := 0
+			for b.Loop() {
+				// one hit and one insertion (with eviction) per iteration
+				c.Get(i + capacity/2)
+				c.Set(i+capacity, i)
+				i++
+			}
+		})
+	}
+}
@@ -1,23 +1,41 @@
 package ordered
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"maps"
-	"reflect"
 	"slices"
-	"unsafe"
 )
 
+// Map is a map that keeps its keys in insertion order.
+//
+// Entries are stored in a slice indexed by key, deleted entries are
+// left as holes and compacted once they make up half of the slice,
+// so Set, Get, Del and iteration are all amortized O(1).
 type Map[K comparable, V any] struct {
-	m    map[K]V
-	keys []K
+	index   map[K]int     // key to position in entries
+	entries []entry[K, V] // ordered entries, may contain holes
+	holes   int           // number of deleted entries in entries
+	first   int           // position of the first entry in entries, the ones before it are all holes
+	shared  bool          // index and entries are shared with a snapshot and must be copied before writing
+
+	observers *observers[K, V]
+
+	nestedMaps bool
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	deleted bool
 }
 
 var (
 	ErrKeyTypeNotString = errors.New("key type must be string")
 	ErrNilOrderedMap    = errors.New("calling MarshalJSON on nil OrderedMap")
+	// ErrUnsupportedKeyType is returned when encoding or decoding JSON with keys
+	// that encoding/json does not support for maps either. It wraps ErrKeyTypeNotString.
+	ErrUnsupportedKeyType = fmt.Errorf("%w, an integer or implement encoding.TextMarshaler", ErrKeyTypeNotString)
 )
 
 func NewMap[K comparable, V any](opts ...Option) *Map[K, V] {
@@ -26,158 +44,250 @@ func NewMap[K comparable, V any](opts ...Option) *Map[K, V] {
 		o(&opt)
 	}
 	m := &Map[K, V]{
-		m:    make(map[K]V, opt.capacity),
-		keys: make([]K, 0, opt.capacity),
+		index:   make(map[K]int, opt.capacity),
+		entries: make([]entry[K, V], 0, opt.capacity),
+
+		nestedMaps: opt.nestedMaps,
 	}
 	return m
 }
 
 func (o *Map[K, V]) Set(key K, value V) {
-	oldSize := len(o.m)
-	o.m[key] = value
-	if len(o.m) > oldSize { // new key added
-		o.keys = append(o.keys, key)
+	o.own()
+	if i, ok := o.index[key]; ok {
+		old := o.entries[i].value
+		o.entries[i].value = value
+		if o.observers != nil {
+			o.notify(Event[K, V]{Kind: EventUpdated, Key: key, Value: value, OldValue: old})
+		}
+		return
+	}
+	if o.index == nil { // zero value Map
+		o.index = make(map[K]int)
+	}
+	o.index[key] = len(o.entries)
+	o.entries = append(o.entries, entry[K, V]{key: key, value: value})
+	if o.observers != nil {
+		o.notify(Event[K, V]{Kind: EventInserted, Key: key, Value: value, Index: o.Len() - 1})
 	}
 }
 
 func (o *Map[K, V]) Get(key K) V {
-	return o.m[key]
+	if i, ok := o.index[key]; ok {
+		return o.entries[i].value
+	}
+	var zero V
+	return zero
 }
 
 func (o *Map[K, V]) TryGet(key K) (V, bool) {
-	value, ok := o.m[key]
-	return value, ok
+	if i, ok := o.index[key]; ok {
+		return o.entries[i].value, true
+	}
+	var zero V
+	return zero, false
 }
 
 func (o *Map[K, V]) Contains(key K) bool {
-	_, ok := o.m[key]
+	_, ok := o.index[key]
 	return ok
 }
 
 func (o *Map[K, V]) Del(key K) {
-	oldSize := o.Len()
-	if oldSize == 0 {
+	i, ok := o.index[key]
+	if !ok {
 		return
 	}
-
-	delete(o.m, key)
-	if len(o.m) == oldSize { // key not found
-		return
+	o.own()
+	i = o.index[key]
+	value := o.entries[i].value
+	delete(o.index, key)
+	o.removeAt(i)
+	if o.observers != nil {
+		o.notify(Event[K, V]{Kind: EventDeleted, Key: key, Value: value})
 	}
+}
 
-	if oldSize == 1 {
-		o.keys = o.keys[:0]
+// removeAt removes the entry at position i of entries,
+// the caller is responsible for updating index.
+func (o *Map[K, V]) removeAt(i int) {
+	if i == len(o.entries)-1 {
+		// last entry, shrink instead of leaving a hole (and drop trailing holes)
+		o.entries[i] = entry[K, V]{}
+		o.entries = o.entries[:i]
+		for n := len(o.entries); n > 0 && o.entries[n-1].deleted; n-- {
+			o.entries[n-1] = entry[K, V]{}
+			o.entries = o.entries[:n-1]
+			o.holes--
+		}
+		o.first = min(o.first, len(o.entries))
 		return
 	}
 
-	idx := slices.Index(o.keys, key)
-	if idx == -1 {
-		panic("race condition in OrderedMap.Del")
+	// zero the entry to release references held by key and value
+	o.entries[i] = entry[K, V]{deleted: true}
+	o.holes++
+	if i == o.first {
+		for o.entries[o.first].deleted {
+			o.first++
+		}
 	}
+	if o.holes*2 > len(o.entries) {
+		o.compact()
+	}
+}
 
-	o.keys = slices.Delete(o.keys, idx, idx+1)
+// compact removes the holes left by deleted entries.
+func (o *Map[K, V]) compact() {
+	if o.holes == 0 {
+		return
+	}
+	n := 0
+	for n < len(o.entries) && !o.entries[n].deleted {
+		n++
+	}
+	for i := n + 1; i < len(o.entries); i++ {
+		if o.entries[i].deleted {
+			continue
+		}
+		o.entries[n] = o.entries[i]
+		o.index[o.entries[n].key] = n
+		n++
+	}
+	clear(o.entries[n:])
+	o.entries = o.entries[:n]
+	o.holes = 0
+	o.first = 0
 }
 
 func (o *Map[K, V]) Len() int {
-	return len(o.keys)
+	return len(o.index)
 }
 
+// Keys returns a copy of the keys in order.
+// Use KeysSeq or KeysView to access them without copying.
 func (o *Map[K, V]) Keys() []K {
-	return o.keys
+	keys := make([]K, 0, o.Len())
+	for i := range o.entries {
+		if !o.entries[i].deleted {
+			keys = append(keys, o.entries[i].key)
+		}
+	}
+	return keys
 }
 
 func (o *Map[K, V]) Values() []V {
-	values := make([]V, len(o.keys))
-	for i, key := range o.keys {
-		values[i] = o.m[key]
+	values := make([]V, 0, o.Len())
+	for i := range o.entries {
+		if !o.entries[i].deleted {
+			values = append(values, o.entries[i].value)
+		}
 	}
 	return values
 }
 
 func (o *Map[K, V]) Iter(yield func(key K, value V) bool) {
-	for _, key := range o.keys {
-		if !yield(key, o.m[key]) {
+	// re-evaluate len(o.entries) on each iteration since yield may modify the map
+	for i := 0; i < len(o.entries); i++ {
+		e := &o.entries[i]
+		if e.deleted {
+			continue
+		}
+		if !yield(e.key, e.value) {
 			break
 		}
 	}
 }
 
 func (o *Map[K, V]) IterKeys(yield func(key K) bool) {
-	for _, key := range o.keys {
-		if !yield(key) {
+	for i := 0; i < len(o.entries); i++ {
+		e := &o.entries[i]
+		if e.deleted {
+			continue
+		}
+		if !yield(e.key) {
 			break
 		}
 	}
 }
 
 func (o *Map[K, V]) IterValues(yield func(value V) bool) {
-	for _, key := range o.keys {
-		if !yield(o.m[key]) {
+	for i := 0; i < len(o.entries); i++ {
+		e := &o.entries[i]
+		if e.deleted {
+			continue
+		}
+		if !yield(e.value) {
 			break
 		}
 	}
 }
 
 func (o *Map[K, V]) Reverse() {
-	slices.Reverse(o.keys)
-}
-
-func (o *Map[K, V]) Clear() {
-	clear(o.m)
-	o.keys = o.keys[:0]
+	o.own()
+	o.compact()
+	slices.Reverse(o.entries)
+	o.reindex(0, len(o.entries))
+	o.notifyReordered()
 }
 
-func (o *Map[K, V]) Clone() *Map[K, V] {
-	return &Map[K, V]{
-		m:    maps.Clone(o.m),
-		keys: slices.Clone(o.keys),
+// reindex updates index for entries[from:to], entries must not contain holes.
+func (o *Map[K, V]) reindex(from, to int) {
+	for i := from; i < to; i++ {
+		o.index[o.entries[i].key] = i
 	}
 }
 
-func (o *Map[K, V]) MarshalJSON() ([]byte, error) {
-	if reflect.TypeFor[K]().Kind() != reflect.String {
-		return nil, ErrKeyTypeNotString
-	}
-
-	if o == nil {
-		return nil, ErrNilOrderedMap
+func (o *Map[K, V]) Clear() {
+	if o.shared {
+		o.index = make(map[K]int, len(o.index))
+		o.entries = make([]entry[K, V], 0, cap(o.entries))
+		o.shared = false
+	} else {
+		clear(o.index)
+		clear(o.entries)
+		o.entries = o.entries[:0]
 	}
-
-	if o.Len() == 0 {
-		return []byte("{}"), nil
+	o.holes = 0
+	o.first = 0
+	if o.observers != nil {
+		o.notify(Event[K, V]{Kind: EventCleared})
 	}
+}
 
-	// can just convert it directly to string slice to avoid unnecessary allocation
-	strKeys := *(*[]string)(unsafe.Pointer(&o.keys))
-
-	// handle root keys to preserve the insertion order
-	buf := bytes.NewBuffer(make([]byte, 0, o.Len()*20))
-	// using json.Encoder instead of json.Marshal
-	// because we don't want to allocate a new byte slice for every key and value
-	je := json.NewEncoder(buf)
+func (o *Map[K, V]) Clone() *Map[K, V] {
+	if o.holes == 0 {
+		return &Map[K, V]{
+			index:   maps.Clone(o.index),
+			entries: slices.Clone(o.entries),
 
-	buf.WriteByte('{')
-	for i, key := range strKeys {
-		if i > 0 {
-			buf.WriteByte(',')
+			nestedMaps: o.nestedMaps,
 		}
+	}
+	clone := &Map[K, V]{
+		index:   make(map[K]int, o.Len()),
+		entries: make([]entry[K, V], 0, o.Len()),
 
-		writeEscapedString(buf, key)
-		buf.WriteByte(':')
-
-		err := je.Encode(o.m[o.keys[i]])
-		if err != nil {
-			return nil, err
-		}
+		nestedMaps: o.nestedMaps,
+	}
+	for k, v := range o.Iter {
+		clone.index[k] = len(clone.entries)
+		clone.entries = append(clone.entries, entry[K, V]{key: k, value: v})
+	}
+	return clone
+}
 
-		buf.Truncate(buf.Len() - 1) // remove the trailing newline that Encode adds
+func (o *Map[K, V]) MarshalJSON() ([]byte, error) {
+	if o == nil {
+		return nil, ErrNilOrderedMap
 	}
-	buf.WriteByte('}')
-	return buf.Bytes(), nil
+	return appendJSON(o)
 }
 
 // OrderedMapMerge merges the given ordered maps into a new ordered map.
 // Similar to array_merge in PHP.
+//
+// The first value of a key wins, see MapMergeWith for other strategies.
 func MapMerge[K comparable, V any](m ...*Map[K, V]) *Map[K, V] {
 	if len(m) == 0 {
 		return NewMap[K, V]()
@@ -187,32 +297,18 @@ func MapMerge[K comparable, V any](m ...*Map[K, V]) *Map[K, V] {
 		return m[0].Clone() // Clone to avoid modifying the original
 	}
 
-	var (
-		keys   []K
-		values map[K]V
-	)
-
 	totalSize := 0
 	for _, om := range m {
 		totalSize += om.Len()
 	}
 
-	seen := make(map[K]bool, totalSize)
-	values = make(map[K]V, totalSize)
-	keys = make([]K, 0, totalSize)
-
+	merged := NewMap[K, V](WithCapacity(totalSize))
 	for _, om := range m {
-		for _, key := range om.keys {
-			if !seen[key] {
-				seen[key] = true
-				keys = append(keys, key)
-				values[key] = om.m[key]
+		for key, value := range om.Iter {
+			if !merged.Contains(key) {
+				merged.Set(key, value)
 			}
 		}
 	}
-
-	return &Map[K, V]{
-		m:    values,
-		keys: keys,
-	}
+	return merged
 }
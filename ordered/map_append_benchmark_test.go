@@ -0,0 +1,195 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// legacyMap is the Map of the baseline, its MarshalJSON and writeEscapedString
+// are copied verbatim to compare the fast path with the encoding it replaced.
+type legacyMap[K comparable, V any] struct {
+	m    map[K]V
+	keys []K
+}
+
+func (o *legacyMap[K, V]) Len() int {
+	return len(o.m)
+}
+
+func (o *legacyMap[K, V]) MarshalJSON() ([]byte, error) {
+	if reflect.TypeFor[K]().Kind() != reflect.String {
+		return nil, ErrKeyTypeNotString
+	}
+
+	if o == nil {
+		return nil, ErrNilOrderedMap
+	}
+
+	if o.Len() == 0 {
+		return []byte("{}"), nil
+	}
+
+	// can just convert it directly to string slice to avoid unnecessary allocation
+	strKeys := *(*[]string)(unsafe.Pointer(&o.keys))
+
+	// handle root keys to preserve the insertion order
+	buf := bytes.NewBuffer(make([]byte, 0, o.Len()*20))
+	// using json.Encoder instead of json.Marshal
+	// because we don't want to allocate a new byte slice for every key and value
+	je := json.NewEncoder(buf)
+
+	buf.WriteByte('{')
+	for i, key := range strKeys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		writeEscapedString(buf, key)
+		buf.WriteByte(':')
+
+		err := je.Encode(o.m[o.keys[i]])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Truncate(buf.Len() - 1) // remove the trailing newline that Encode adds
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func writeEscapedString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if htmlSafeSet[b] || safeSet[b] {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '\\', '"':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\b':
+				buf.WriteByte('\\')
+				buf.WriteByte('b')
+			case '\f':
+				buf.WriteByte('\\')
+				buf.WriteByte('f')
+			case '\n':
+				buf.WriteByte('\\')
+				buf.WriteByte('n')
+			case '\r':
+				buf.WriteByte('\\')
+				buf.WriteByte('r')
+			case '\t':
+				buf.WriteByte('\\')
+				buf.WriteByte('t')
+			default:
+				// This encodes bytes < 0x20 except for \b, \f, \n, \r and \t.
+				// If escapeHTML is set, it also escapes <, >, and &
+				// because they can lead to security holes when
+				// user-controlled strings are rendered into JSON
+				// and served to some browsers.
+				buf.WriteByte('\\')
+				buf.WriteByte('u')
+				buf.WriteByte('0')
+				buf.WriteByte('0')
+				buf.WriteByte(hex[b>>4])
+				buf.WriteByte(hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		// TODO(https://go.dev/issue/56948): Use generic utf8 functionality.
+		// For now, cast only a small portion of byte slices to a string
+		// so that it can be stack allocated. This slows down []byte slightly
+		// due to the extra copy, but keeps string performance roughly the same.
+		n := min(len(s)-i, utf8.UTFMax)
+		c, size := utf8.DecodeRuneInString(s[i : i+n])
+		if c == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\ufffd`)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 is LINE SEPARATOR.
+		// U+2029 is PARAGRAPH SEPARATOR.
+		// They are both technically valid characters in JSON strings,
+		// but don't work in JSONP, which has to be evaluated as JavaScript,
+		// and can lead to security holes there. It is valid JSON to
+		// escape them, so we do so unconditionally.
+		// See https://en.wikipedia.org/wiki/JSON#Safety.
+		if c == '\u2028' || c == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hex[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	om := NewMap[string, any](WithCapacity(1000))
+	for i := range 1000 {
+		switch i % 4 {
+		case 0:
+			om.Set("key"+strconv.Itoa(i), "value"+strconv.Itoa(i))
+		case 1:
+			om.Set("key"+strconv.Itoa(i), i)
+		case 2:
+			om.Set("key"+strconv.Itoa(i), float64(i)/3)
+		case 3:
+			om.Set("key"+strconv.Itoa(i), i%2 == 0)
+		}
+	}
+
+	legacy := &legacyMap[string, any]{m: make(map[string]any, om.Len())}
+	for k, v := range om.Iter {
+		legacy.m[k] = v
+		legacy.keys = append(legacy.keys, k)
+	}
+
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := legacy.MarshalJSON(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("MarshalJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := om.MarshalJSON(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("AppendJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for b.Loop() {
+			var err error
+			if buf, err = om.AppendJSON(buf[:0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
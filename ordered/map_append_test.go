@@ -0,0 +1,190 @@
+package ordered
+
+import (
+	"encoding/json"
+	"math"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ptrMarshaler struct{ v int }
+
+func (p *ptrMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`"ptr"`), nil
+}
+
+type valueMarshaler string
+
+func (v valueMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`"value:` + string(v) + `"`), nil
+}
+
+// requireSameAsStd checks that AppendJSON encodes each value like encoding/json.
+func requireSameAsStd[V any](t *testing.T, values ...V) {
+	t.Helper()
+	for i, v := range values {
+		om := NewMap[string, V]()
+		om.Set("k", v)
+		got, err := om.AppendJSON(nil)
+		require.NoError(t, err)
+
+		want, err := json.Marshal(map[string]V{"k": v})
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got), "value %d: %#v", i, v)
+	}
+}
+
+func TestMap_AppendJSON(t *testing.T) {
+	t.Run("same as encoding/json", func(t *testing.T) {
+		requireSameAsStd(t, "", "plain", "<html> & \"quotes\"", " \x01", "日本")
+		requireSameAsStd(t, true, false)
+		requireSameAsStd(t, 0, -1, math.MaxInt64, math.MinInt64)
+		requireSameAsStd(t, int32(-5), math.MinInt32)
+		requireSameAsStd(t, int64(7))
+		requireSameAsStd(t, uint(1))
+		requireSameAsStd(t, uint32(2))
+		requireSameAsStd(t, uint64(math.MaxUint64))
+		requireSameAsStd(t, 0.0, 1.5, -2.25, 1e-7, 1e21, 123456789.125, math.SmallestNonzeroFloat64, math.MaxFloat64)
+		requireSameAsStd(t, float32(0.1), float32(1e-7), float32(3.4e38))
+		requireSameAsStd(t, []byte(nil), []byte{}, []byte("hello, world"))
+		requireSameAsStd(t,
+			time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+			time.Date(1, 1, 1, 0, 0, 0, 0, time.FixedZone("x", 3600)),
+			time.Time{},
+		)
+		requireSameAsStd[any](t, nil, "s", 1, 1.5, int8(1), uint8(2), []byte("b"), []any{1, "a", nil}, map[string]any{"b": 1, "a": 2})
+		requireSameAsStd(t, valueMarshaler("x"))
+		requireSameAsStd(t, ptrMarshaler{1})
+		requireSameAsStd(t, &ptrMarshaler{1})
+		requireSameAsStd(t, netip.MustParseAddr("::1"))
+		requireSameAsStd(t, struct {
+			A int    `json:"a"`
+			B string `json:"b,omitempty"`
+		}{A: 1})
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		ints := NewMap[int8, int]()
+		ints.Set(-128, 1)
+		ints.Set(127, 2)
+		got, err := ints.AppendJSON(nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"-128":1,"127":2}`, string(got))
+
+		addrs := NewMap[netip.Addr, int]()
+		addrs.Set(netip.MustParseAddr("10.0.0.1"), 1)
+		got, err = addrs.AppendJSON(nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"10.0.0.1":1}`, string(got))
+
+		html := NewMap[string, int]()
+		html.Set("<a>", 1)
+		got, err = html.AppendJSON(nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"\u003ca\u003e":1}`, string(got))
+		got, err = html.AppendJSON(nil, WithEscapeHTML(false))
+		require.NoError(t, err)
+		require.Equal(t, `{"<a>":1}`, string(got))
+		// MarshalJSON leaves the top-level keys unescaped, like the encoding it replaced
+		nested := NewMap[string, any]()
+		nested.Set("<n>", "&")
+		om := NewMap[string, any]()
+		om.Set("<a>&", "<v>")
+		om.Set("b", nested)
+		legacy := &legacyMap[string, any]{m: map[string]any{"<a>&": "<v>", "b": nested}, keys: []string{"<a>&", "b"}}
+		want, err := legacy.MarshalJSON()
+		require.NoError(t, err)
+		got, err = om.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, `{"<a>&":"\u003cv\u003e","b":{"\u003cn\u003e":"\u0026"}}`, string(got))
+		require.Equal(t, string(want), string(got))
+	})
+
+	t.Run("invalid utf-8", func(t *testing.T) {
+		om := NewMap[string, string]()
+		om.Set("k", "a\xffb")
+		got, err := om.AppendJSON(nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"k":"a\ufffdb"}`, string(got))
+	})
+
+	t.Run("appends", func(t *testing.T) {
+		om := newDiffTestMap("a", 1, "b", 2)
+		om.Del("a")
+		got, err := om.AppendJSON([]byte("x="))
+		require.NoError(t, err)
+		require.Equal(t, `x={"b":2}`, string(got))
+
+		got, err = NewMap[string, int]().AppendJSON(nil, WithIndent("", "  "))
+		require.NoError(t, err)
+		require.Equal(t, `{}`, string(got))
+
+		got, err = om.AppendJSON(nil, WithIndent("", "  "))
+		require.NoError(t, err)
+		require.Equal(t, "{\n  \"b\": 2\n}", string(got))
+	})
+
+	t.Run("errors keep dst", func(t *testing.T) {
+		om := NewMap[string, float64]()
+		om.Set("a", 1)
+		om.Set("b", math.NaN())
+		dst := []byte("x")
+		got, err := om.AppendJSON(dst)
+		require.Error(t, err)
+		require.Equal(t, "x", string(got))
+
+		times := NewMap[string, time.Time]()
+		times.Set("t", time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC))
+		_, err = times.AppendJSON(nil)
+		require.Error(t, err)
+
+		var nilMap *Map[string, int]
+		_, err = nilMap.AppendJSON(nil)
+		require.ErrorIs(t, err, ErrNilOrderedMap)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		s := NewSet[string]()
+		s.Add("y")
+		s.Add("x")
+		inner := NewMap[string, any]()
+		inner.Set("set", s)
+		inner.Set("frozen", newDiffTestMap("z", 1, "a", 2).Freeze())
+		om := NewMap[string, *Map[string, any]]()
+		om.Set("inner", inner)
+		om.Set("nil", nil)
+
+		got, err := om.AppendJSON(nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"inner":{"set":["y","x"],"frozen":{"z":1,"a":2}},"nil":null}`, string(got))
+	})
+
+	t.Run("allocations", func(t *testing.T) {
+		om := NewMap[string, any]()
+		for i := range 100 {
+			om.Set(strconv.Itoa(i), i)
+		}
+		strs := NewMap[int, string]()
+		for i := range 100 {
+			strs.Set(i, strconv.Itoa(i))
+		}
+		dst := make([]byte, 0, 4096)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = strs.AppendJSON(dst[:0])
+		})
+		require.Zero(t, allocs)
+		allocs = testing.AllocsPerRun(100, func() {
+			_, _ = om.AppendJSON(dst[:0])
+		})
+		require.LessOrEqual(t, allocs, 1.0)
+		allocs = testing.AllocsPerRun(100, func() {
+			_, _ = strs.MarshalJSON()
+		})
+		require.LessOrEqual(t, allocs, 2.0)
+	})
+}
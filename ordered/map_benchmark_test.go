@@ -64,39 +64,74 @@ func BenchmarkSet(b *testing.B) {
 }
 
 func BenchmarkDel(b *testing.B) {
-	const size = 1000
+	for _, size := range []int{1000, 10000, 100000} {
+		b.Run("orderedmap/"+strconv.Itoa(size), func(b *testing.B) {
+			om := NewMap[string, int](WithCapacity(size))
+			for i := range size {
+				om.Set("key"+strconv.Itoa(i), i)
+			}
 
-	b.Run("orderedmap", func(b *testing.B) {
-		om := NewMap[string, int](WithCapacity(size))
-		for i := range size {
-			om.Set("key"+strconv.Itoa(i), i)
-		}
+			for b.Loop() {
+				b.StopTimer()
+				omClone := om.Clone()
+				b.StartTimer()
+				for i := range size {
+					omClone.Del("key" + strconv.Itoa(i))
+				}
+			}
+		})
 
-		for b.Loop() {
-			b.StopTimer()
-			omClone := om.Clone()
-			b.StartTimer()
+		b.Run("map/"+strconv.Itoa(size), func(b *testing.B) {
+			m := make(map[string]int, size)
 			for i := range size {
-				omClone.Del("key" + strconv.Itoa(i))
+				m["key"+strconv.Itoa(i)] = i
 			}
-		}
-	})
 
-	b.Run("map", func(b *testing.B) {
-		m := make(map[string]int, size)
-		for i := range size {
-			m["key"+strconv.Itoa(i)] = i
-		}
+			for b.Loop() {
+				b.StopTimer()
+				mClone := maps.Clone(m)
+				b.StartTimer()
+				for i := range size {
+					delete(mClone, "key"+strconv.Itoa(i))
+				}
+			}
+		})
+	}
+}
 
-		for b.Loop() {
-			b.StopTimer()
-			mClone := maps.Clone(m)
-			b.StartTimer()
+// BenchmarkDelChurn deletes a key and adds a new one on every iteration,
+// keeping the map at a constant (large) size.
+func BenchmarkDelChurn(b *testing.B) {
+	for _, size := range []int{10000, 100000} {
+		b.Run("orderedmap/"+strconv.Itoa(size), func(b *testing.B) {
+			om := NewMap[int, int](WithCapacity(size))
 			for i := range size {
-				delete(mClone, "key"+strconv.Itoa(i))
+				om.Set(i, i)
 			}
-		}
-	})
+
+			i := 0
+			for b.Loop() {
+				// delete from the middle of the order to avoid the trailing fast path
+				om.Del(i + size/2)
+				om.Set(i+size, i)
+				i++
+			}
+		})
+
+		b.Run("map/"+strconv.Itoa(size), func(b *testing.B) {
+			m := make(map[int]int, size)
+			for i := range size {
+				m[i] = i
+			}
+
+			i := 0
+			for b.Loop() {
+				delete(m, i+size/2)
+				m[i+size] = i
+				i++
+			}
+		})
+	}
 }
 
 func BenchmarkIter(b *testing.B) {
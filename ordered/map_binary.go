@@ -0,0 +1,379 @@
+package ordered
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/yusing/ds/ordered/internal/codec"
+)
+
+// binaryVersion is the first byte of the binary encoding of Map and Set,
+// it is bumped whenever the layout that follows changes.
+//
+// Version 1 is followed by the map, or the array of set elements, as tagged values.
+const binaryVersion byte = 1
+
+// The tags of the values in the binary encoding, lengths and counts are uvarints.
+const (
+	binaryNil byte = iota
+	binaryFalse
+	binaryTrue
+	binaryInt     // zig-zag varint
+	binaryUint    // uvarint
+	binaryFloat32 // 4 bytes, little endian
+	binaryFloat64 // 8 bytes, little endian
+	binaryString  // length, bytes
+	binaryBytes   // length, bytes
+	binaryTime    // length, time.Time.MarshalBinary
+	binaryArray   // count, elements
+	binaryMap     // count, keys and values in order
+	binaryType    // length, name registered with RegisterBinaryName, value
+)
+
+var ErrBinaryVersion = errors.New("unsupported binary encoding version")
+
+func init() {
+	codec.NewStringMap = func(capacity int) (any, func(string, any)) {
+		m := NewMap[string, any](WithCapacity(capacity), WithNestedMaps())
+		return m, m.Set
+	}
+	codec.NewAnyMap = func(capacity int) (any, func(any, any)) {
+		m := NewMap[any, any](WithCapacity(capacity))
+		return m, m.Set
+	}
+}
+
+var binaryTypes struct {
+	mu     sync.Mutex
+	byName sync.Map // string -> reflect.Type
+	byType sync.Map // reflect.Type -> string
+}
+
+// RegisterBinaryName records the concrete type of value under name, so that values
+// of that type held in interfaces, like the values of a Map[string, any], are decoded
+// by UnmarshalBinary as that type rather than as the natural type of their encoding.
+// Like gob.RegisterName, it panics if the type or the name is already registered
+// with another name or type.
+func RegisterBinaryName(name string, value any) {
+	t := reflect.TypeOf(value)
+	if t == nil || name == "" {
+		panic("ordered: RegisterBinaryName with a nil value or an empty name")
+	}
+	binaryTypes.mu.Lock()
+	defer binaryTypes.mu.Unlock()
+	if other, ok := binaryTypes.byName.Load(name); ok && other != t {
+		panic(fmt.Sprintf("ordered: registering duplicate types for %q: %s != %s", name, other, t))
+	}
+	if other, ok := binaryTypes.byType.Load(t); ok && other != name {
+		panic(fmt.Sprintf("ordered: registering duplicate names for %s: %q != %q", t, other, name))
+	}
+	binaryTypes.byName.Store(name, t)
+	binaryTypes.byType.Store(t, name)
+}
+
+// RegisterBinary registers the type of value under its name, see RegisterBinaryName.
+// Named types, and pointers to them, are named after their package path.
+func RegisterBinary(value any) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		panic("ordered: RegisterBinary with a nil value")
+	}
+	star := ""
+	if t.Name() == "" && t.Kind() == reflect.Pointer {
+		star, t = "*", t.Elem()
+	}
+	name := t.String()
+	if t.Name() != "" && t.PkgPath() != "" {
+		name = t.PkgPath() + "." + t.Name()
+	}
+	RegisterBinaryName(star+name, value)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, preserving the order of the entries.
+//
+// Keys and values are encoded like with ordered/msgpack: nested ordered containers in
+// order, structs as maps of their exported fields and types implementing
+// encoding.TextMarshaler as strings. Values held in interfaces are decoded as nil,
+// bool, int64, uint64, float64, string, []byte, time.Time, []any or *Map[string, any],
+// unless their type is registered with RegisterBinary.
+func (o *Map[K, V]) MarshalBinary() ([]byte, error) {
+	if o == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return marshalBinary(o)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the entries of the map.
+// The map is left unchanged if data is invalid.
+func (o *Map[K, V]) UnmarshalBinary(data []byte) error {
+	decoded := NewMap[K, V]()
+	if err := unmarshalBinary(data, decoded); err != nil {
+		return err
+	}
+	o.Clear()
+	for k, v := range decoded.Iter {
+		o.Set(k, v)
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder with the encoding of MarshalBinary.
+func (o *Map[K, V]) GobEncode() ([]byte, error) {
+	return o.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder with the encoding of UnmarshalBinary.
+func (o *Map[K, V]) GobDecode(data []byte) error {
+	return o.UnmarshalBinary(data)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, preserving the order of the elements.
+// The elements are encoded like the keys of Map.MarshalBinary.
+func (s *Set[T]) MarshalBinary() ([]byte, error) {
+	if s == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return marshalBinary(s)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the elements of the set.
+// The set is left unchanged if data is invalid.
+func (s *Set[T]) UnmarshalBinary(data []byte) error {
+	decoded := NewSet[T]()
+	if err := unmarshalBinary(data, decoded); err != nil {
+		return err
+	}
+	s.Clear()
+	for key := range decoded.Iter {
+		s.Add(key)
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder with the encoding of MarshalBinary.
+func (s *Set[T]) GobEncode() ([]byte, error) {
+	return s.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder with the encoding of UnmarshalBinary.
+func (s *Set[T]) GobDecode(data []byte) error {
+	return s.UnmarshalBinary(data)
+}
+
+func marshalBinary(v any) ([]byte, error) {
+	w := binaryWriter{buf: []byte{binaryVersion}}
+	if err := codec.Encode(&w, v, "binary"); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+func unmarshalBinary(data []byte, v any) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty data", ErrBinaryVersion)
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("%w: %d", ErrBinaryVersion, data[0])
+	}
+	r := binaryReader{data: data, off: 1}
+	if err := codec.Decode(&r, v, "binary"); err != nil {
+		return err
+	}
+	if r.off != len(data) {
+		return fmt.Errorf("binary: %d bytes of trailing data", len(data)-r.off)
+	}
+	return nil
+}
+
+type binaryWriter struct {
+	buf []byte
+}
+
+func (w *binaryWriter) Nil() {
+	w.buf = append(w.buf, binaryNil)
+}
+
+func (w *binaryWriter) Bool(b bool) {
+	if b {
+		w.buf = append(w.buf, binaryTrue)
+	} else {
+		w.buf = append(w.buf, binaryFalse)
+	}
+}
+
+func (w *binaryWriter) Int(i int64) {
+	w.buf = binary.AppendVarint(append(w.buf, binaryInt), i)
+}
+
+func (w *binaryWriter) Uint(u uint64) {
+	w.buf = binary.AppendUvarint(append(w.buf, binaryUint), u)
+}
+
+func (w *binaryWriter) Float32(f float32) {
+	w.buf = binary.LittleEndian.AppendUint32(append(w.buf, binaryFloat32), math.Float32bits(f))
+}
+
+func (w *binaryWriter) Float64(f float64) {
+	w.buf = binary.LittleEndian.AppendUint64(append(w.buf, binaryFloat64), math.Float64bits(f))
+}
+
+func (w *binaryWriter) String(s string) {
+	w.buf = binary.AppendUvarint(append(w.buf, binaryString), uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *binaryWriter) Bytes(b []byte) {
+	w.buf = binary.AppendUvarint(append(w.buf, binaryBytes), uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *binaryWriter) Time(t time.Time) {
+	b, err := t.MarshalBinary()
+	if err != nil { // the zone offset is out of range
+		b, _ = t.UTC().MarshalBinary()
+	}
+	w.buf = binary.AppendUvarint(append(w.buf, binaryTime), uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *binaryWriter) ArrayHeader(n int) {
+	w.buf = binary.AppendUvarint(append(w.buf, binaryArray), uint64(n))
+}
+
+func (w *binaryWriter) MapHeader(n int) {
+	w.buf = binary.AppendUvarint(append(w.buf, binaryMap), uint64(n))
+}
+
+// Type writes the name of t if it is registered.
+func (w *binaryWriter) Type(t reflect.Type) bool {
+	name, ok := binaryTypes.byType.Load(t)
+	if !ok {
+		return false
+	}
+	w.buf = binary.AppendUvarint(append(w.buf, binaryType), uint64(len(name.(string))))
+	w.buf = append(w.buf, name.(string)...)
+	return true
+}
+
+type binaryReader struct {
+	data []byte
+	off  int
+}
+
+var errBinaryTruncated = errors.New("binary: unexpected end of data")
+
+func (r *binaryReader) Next() (codec.Token, error) {
+	if r.off >= len(r.data) {
+		return codec.Token{}, errBinaryTruncated
+	}
+	tag := r.data[r.off]
+	r.off++
+
+	switch tag {
+	case binaryNil:
+		return codec.Token{Kind: codec.KindNil}, nil
+	case binaryFalse, binaryTrue:
+		return codec.Token{Kind: codec.KindBool, Bool: tag == binaryTrue}, nil
+	case binaryInt:
+		i, n := binary.Varint(r.data[r.off:])
+		if n <= 0 {
+			return codec.Token{}, errBinaryTruncated
+		}
+		r.off += n
+		if i < 0 {
+			return codec.Token{Kind: codec.KindInt, Int: i}, nil
+		}
+		return codec.Token{Kind: codec.KindUint, Uint: uint64(i)}, nil
+	case binaryUint:
+		u, err := r.uvarint()
+		return codec.Token{Kind: codec.KindUint, Uint: u}, err
+	case binaryFloat32:
+		b, err := r.bytes(4)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindFloat, Float: float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))}, nil
+	case binaryFloat64:
+		b, err := r.bytes(8)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindFloat, Float: math.Float64frombits(binary.LittleEndian.Uint64(b))}, nil
+	case binaryString, binaryBytes, binaryTime, binaryType:
+		n, err := r.length(1)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		b, err := r.bytes(n)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		switch tag {
+		case binaryString:
+			return codec.Token{Kind: codec.KindString, Bytes: b}, nil
+		case binaryBytes:
+			return codec.Token{Kind: codec.KindBytes, Bytes: b}, nil
+		case binaryTime:
+			var t time.Time
+			if err := t.UnmarshalBinary(b); err != nil {
+				return codec.Token{}, fmt.Errorf("binary: %w", err)
+			}
+			return codec.Token{Kind: codec.KindTime, Time: t}, nil
+		default:
+			t, ok := binaryTypes.byName.Load(string(b))
+			if !ok {
+				return codec.Token{}, fmt.Errorf("binary: type %q is not registered", b)
+			}
+			return codec.Token{Kind: codec.KindType, Type: t.(reflect.Type)}, nil
+		}
+	case binaryArray:
+		n, err := r.length(1)
+		return codec.Token{Kind: codec.KindArray, Len: n}, err
+	case binaryMap:
+		n, err := r.length(2)
+		return codec.Token{Kind: codec.KindMap, Len: n}, err
+	default:
+		return codec.Token{}, fmt.Errorf("binary: invalid tag %#x at offset %d", tag, r.off-1)
+	}
+}
+
+// Break reports false, all lengths are definite.
+func (r *binaryReader) Break() (bool, error) {
+	return false, nil
+}
+
+func (r *binaryReader) uvarint() (uint64, error) {
+	u, n := binary.Uvarint(r.data[r.off:])
+	if n <= 0 {
+		return 0, errBinaryTruncated
+	}
+	r.off += n
+	return u, nil
+}
+
+// length reads a length or count of items of at least size bytes each,
+// and checks that they fit in the data left.
+func (r *binaryReader) length(size int) (int, error) {
+	u, err := r.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(r.data)-r.off)/uint64(size) {
+		return 0, errBinaryTruncated
+	}
+	return int(u), nil
+}
+
+func (r *binaryReader) bytes(n int) ([]byte, error) {
+	if n > len(r.data)-r.off {
+		return nil, errBinaryTruncated
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
@@ -0,0 +1,195 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*Map[string, int])(nil)
+	_ encoding.BinaryUnmarshaler = (*Map[string, int])(nil)
+	_ gob.GobEncoder             = (*Set[string])(nil)
+	_ gob.GobDecoder             = (*Set[string])(nil)
+)
+
+// gobRoundTrip encodes v with gob and decodes it into a new T.
+func gobRoundTrip[T any](t *testing.T, v T) T {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(v))
+	var decoded T
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	return decoded
+}
+
+func TestMap_Binary(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		om := newDiffTestMap("c", 3, "a", 1, "b", 2, "d", 4)
+		om.Del("a")
+		data, err := om.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, binaryVersion, data[0])
+
+		decoded := NewMap[string, int]()
+		decoded.Set("stale", 0)
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Equal(t, []string{"c", "b", "d"}, decoded.Keys())
+		require.Equal(t, []int{3, 2, 4}, decoded.Values())
+
+		empty, err := NewMap[int, string]().MarshalBinary()
+		require.NoError(t, err)
+		ints := NewMap[int, string]()
+		ints.Set(1, "a")
+		require.NoError(t, ints.UnmarshalBinary(empty))
+		require.Zero(t, ints.Len())
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		type cache struct {
+			Name  string
+			Items *Map[int, string]
+			Tags  *Set[string]
+		}
+		items := NewMap[int, string]()
+		items.Set(3, "c")
+		items.Set(1, "a")
+		items.Set(2, "b")
+		tags := NewSet[string]()
+		tags.Add("z")
+		tags.Add("a")
+
+		decoded := gobRoundTrip(t, cache{Name: "routes", Items: items, Tags: tags})
+		require.Equal(t, "routes", decoded.Name)
+		require.Equal(t, []int{3, 1, 2}, decoded.Items.Keys())
+		require.Equal(t, []string{"c", "a", "b"}, decoded.Items.Values())
+		require.Equal(t, []string{"z", "a"}, decoded.Tags.Values())
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		inner := NewMap[string, any]()
+		inner.Set("z", 1)
+		inner.Set("a", []any{"x", 2.5, nil})
+		om := NewMap[string, any]()
+		om.Set("inner", inner)
+		om.Set("n", "v")
+
+		decoded := gobRoundTrip(t, om)
+		require.Equal(t, []string{"inner", "n"}, decoded.Keys())
+		got := decoded.Get("inner").(*Map[string, any])
+		require.Equal(t, []string{"z", "a"}, got.Keys())
+		require.Equal(t, int64(1), got.Get("z"))
+		require.Equal(t, []any{"x", 2.5, nil}, got.Get("a"))
+
+		typed := NewMap[string, *Map[string, int]]()
+		typed.Set("second", newDiffTestMap("y", 2, "x", 1))
+		typed.Set("first", newDiffTestMap("b", 1, "a", 2))
+		decodedTyped := gobRoundTrip(t, typed)
+		require.Equal(t, []string{"second", "first"}, decodedTyped.Keys())
+		require.Equal(t, []string{"y", "x"}, decodedTyped.Get("second").Keys())
+
+		sets := NewMap[string, *Set[int]]()
+		s := NewSet[int]()
+		s.Add(3)
+		s.Add(1)
+		sets.Set("s", s)
+		require.Equal(t, []int{3, 1}, gobRoundTrip(t, sets).Get("s").Values())
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		inner := NewMap[string, any]()
+		inner.Set("k", -1)
+		om := NewMap[string, any]()
+		om.Set("b", "xy")
+		om.Set("a", inner)
+		data, err := om.MarshalBinary()
+		require.NoError(t, err)
+		// nested maps are written inline, without a version of their own
+		require.Equal(t, []byte{
+			binaryVersion, binaryMap, 2,
+			binaryString, 1, 'b', binaryString, 2, 'x', 'y',
+			binaryString, 1, 'a', binaryMap, 1,
+			binaryString, 1, 'k', binaryInt, 1,
+		}, data)
+	})
+
+	t.Run("registered types", func(t *testing.T) {
+		type binaryPoint struct{ X, Y int }
+		type binaryCount int // not int, the registry is global
+		RegisterBinary(binaryPoint{})
+		RegisterBinary(&binaryPoint{})
+		RegisterBinaryName("binary test count", binaryCount(0))
+		require.NotPanics(t, func() { RegisterBinary(binaryPoint{}) })
+		require.Panics(t, func() { RegisterBinaryName("binary test count", "") })
+		require.Panics(t, func() { RegisterBinaryName("other", binaryCount(0)) })
+
+		om := NewMap[string, any]()
+		om.Set("p", binaryPoint{1, 2})
+		om.Set("ptr", &binaryPoint{3, 4})
+		om.Set("n", binaryCount(5))
+		om.Set("list", []any{binaryCount(6), int8(7)})
+		data, err := om.MarshalBinary()
+		require.NoError(t, err)
+
+		decoded := NewMap[string, any]()
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.Equal(t, binaryPoint{1, 2}, decoded.Get("p"))
+		require.Equal(t, &binaryPoint{3, 4}, decoded.Get("ptr"))
+		require.Equal(t, binaryCount(5), decoded.Get("n"))
+		require.Equal(t, []any{binaryCount(6), int64(7)}, decoded.Get("list"))
+
+		// the names are ignored when decoding into concrete types
+		typed := NewMap[string, int]()
+		om = NewMap[string, any]()
+		om.Set("n", binaryCount(5))
+		data, err = om.MarshalBinary()
+		require.NoError(t, err)
+		require.NoError(t, typed.UnmarshalBinary(data))
+		require.Equal(t, 5, typed.Get("n"))
+
+		unknown := []byte{binaryVersion, binaryMap, 1, binaryString, 1, 'a', binaryType, 1, 'x', binaryNil}
+		require.ErrorContains(t, decoded.UnmarshalBinary(unknown), `type "x" is not registered`)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		om := newDiffTestMap("a", 1)
+		require.ErrorIs(t, om.UnmarshalBinary(nil), ErrBinaryVersion)
+		require.ErrorIs(t, om.UnmarshalBinary([]byte{binaryVersion + 1}), ErrBinaryVersion)
+		require.Error(t, om.UnmarshalBinary([]byte{binaryVersion, 0xff, 0x00}))
+		require.ErrorIs(t, om.UnmarshalBinary([]byte{binaryVersion, binaryMap, 0xff, 0xff, 0x03}), errBinaryTruncated)
+		require.ErrorContains(t, om.UnmarshalBinary([]byte{binaryVersion, binaryMap, 0, 0}), "trailing data")
+
+		data, err := newDiffTestMap("a", 1, "b", 2).MarshalBinary()
+		require.NoError(t, err)
+		require.Error(t, om.UnmarshalBinary(data[:len(data)-2]))
+		require.Equal(t, []string{"a"}, om.Keys())
+
+		strs := NewMap[string, string]()
+		require.Error(t, strs.UnmarshalBinary(data))
+
+		var nilMap *Map[string, int]
+		_, err = nilMap.MarshalBinary()
+		require.ErrorIs(t, err, ErrNilOrderedMap)
+	})
+}
+
+func TestSet_Binary(t *testing.T) {
+	s := NewSet[string]()
+	for _, v := range []string{"c", "a", "b", "d"} {
+		s.Add(v)
+	}
+	s.Remove("a")
+	data, err := s.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := NewSet[string]()
+	decoded.Add("stale")
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, []string{"c", "b", "d"}, decoded.Values())
+
+	require.ErrorIs(t, decoded.UnmarshalBinary([]byte{0}), ErrBinaryVersion)
+	require.Equal(t, []string{"c", "b", "d"}, decoded.Values())
+}
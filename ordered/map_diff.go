@@ -0,0 +1,296 @@
+package ordered
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Patch describes how to turn a Map into another, see Diff and Map.Apply.
+//
+// A Patch can be encoded to JSON as long as K and V can.
+type Patch[K comparable, V any] struct {
+	// Removed holds the entries only present in the old map, with their index in it.
+	Removed []PatchEntry[K, V] `json:"removed,omitempty"`
+	// Added holds the entries only present in the new map, with their index in it.
+	Added []PatchEntry[K, V] `json:"added,omitempty"`
+	// Changed holds the keys present in both maps whose value differ.
+	Changed []PatchChange[K, V] `json:"changed,omitempty"`
+	// Moved holds the keys present in both maps whose relative order changed.
+	Moved []PatchMove[K] `json:"moved,omitempty"`
+}
+
+// PatchEntry is an added or removed entry of a Patch.
+type PatchEntry[K comparable, V any] struct {
+	Key   K   `json:"key"`
+	Value V   `json:"value"`
+	Index int `json:"index"`
+}
+
+// PatchChange is an entry of a Patch whose value changed.
+type PatchChange[K comparable, V any] struct {
+	Key      K   `json:"key"`
+	OldValue V   `json:"old_value"`
+	Value    V   `json:"value"`
+	Index    int `json:"index"` // index in the new map
+}
+
+// PatchMove is an entry of a Patch that moved from index From in the old map
+// to index To in the new map.
+type PatchMove[K comparable] struct {
+	Key  K   `json:"key"`
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// ErrPatchConflict is returned by Map.Apply when a patch does not fit the map.
+var ErrPatchConflict = errors.New("patch does not apply")
+
+// Diff returns the patch that turns a into b, comparing values with ==.
+func Diff[K, V comparable](a, b *Map[K, V]) *Patch[K, V] {
+	return DiffFunc(a, b, func(x, y V) bool { return x == y })
+}
+
+// DiffFunc is like Diff but compares values with eq.
+//
+// The moved keys are the fewest needed to fix the order: the keys kept
+// in place form a longest common subsequence of the two orders.
+func DiffFunc[K comparable, V any](a, b *Map[K, V], eq func(x, y V) bool) *Patch[K, V] {
+	p := &Patch[K, V]{}
+
+	posA := make(map[K]int, a.Len())
+	i := 0
+	for k, v := range a.Iter {
+		if !b.Contains(k) {
+			p.Removed = append(p.Removed, PatchEntry[K, V]{Key: k, Value: v, Index: i})
+		}
+		posA[k] = i
+		i++
+	}
+
+	// positions in a of the common keys, in the order of b
+	var common []PatchMove[K]
+	i = 0
+	for k, v := range b.Iter {
+		from, ok := posA[k]
+		if !ok {
+			p.Added = append(p.Added, PatchEntry[K, V]{Key: k, Value: v, Index: i})
+			i++
+			continue
+		}
+		if old := a.Get(k); !eq(old, v) {
+			p.Changed = append(p.Changed, PatchChange[K, V]{Key: k, OldValue: old, Value: v, Index: i})
+		}
+		common = append(common, PatchMove[K]{Key: k, From: from, To: i})
+		i++
+	}
+
+	kept := longestIncreasing(common)
+	for j, mv := range common {
+		if !kept[j] {
+			p.Moved = append(p.Moved, mv)
+		}
+	}
+	return p
+}
+
+// longestIncreasing marks a longest subsequence of moves with increasing From.
+func longestIncreasing[K comparable](moves []PatchMove[K]) []bool {
+	// tails[l] is the index in moves of the smallest tail
+	// of the increasing subsequences of length l+1
+	tails := make([]int, 0, len(moves))
+	prev := make([]int, len(moves))
+	for i, mv := range moves {
+		l := sort.Search(len(tails), func(j int) bool {
+			return moves[tails[j]].From >= mv.From
+		})
+		prev[i] = -1
+		if l > 0 {
+			prev[i] = tails[l-1]
+		}
+		if l == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[l] = i
+		}
+	}
+
+	kept := make([]bool, len(moves))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i != -1; i = prev[i] {
+			kept[i] = true
+		}
+	}
+	return kept
+}
+
+// Empty reports whether the patch has no changes.
+func (p *Patch[K, V]) Empty() bool {
+	return len(p.Removed) == 0 && len(p.Added) == 0 && len(p.Changed) == 0 && len(p.Moved) == 0
+}
+
+// String returns a human readable report of the patch, one change per line.
+func (p *Patch[K, V]) String() string {
+	var sb strings.Builder
+	for _, e := range p.Removed {
+		fmt.Fprintf(&sb, "- [%d] %v: %v\n", e.Index, e.Key, e.Value)
+	}
+	for _, e := range p.Added {
+		fmt.Fprintf(&sb, "+ [%d] %v: %v\n", e.Index, e.Key, e.Value)
+	}
+	for _, c := range p.Changed {
+		fmt.Fprintf(&sb, "~ [%d] %v: %v -> %v\n", c.Index, c.Key, c.OldValue, c.Value)
+	}
+	for _, mv := range p.Moved {
+		fmt.Fprintf(&sb, "> %v: [%d] -> [%d]\n", mv.Key, mv.From, mv.To)
+	}
+	return sb.String()
+}
+
+// Apply applies a patch returned by Diff(a, b) to a map holding the same keys
+// as a, in the same order, turning it into b.
+//
+// The removed values and the old values of the changed entries are not checked.
+// If the patch does not fit the map, including when a removed or moved key is
+// not at its index in a, Apply returns an error wrapping ErrPatchConflict and
+// leaves the map unchanged.
+func (o *Map[K, V]) Apply(p *Patch[K, V]) error {
+	if err := o.checkPatch(p); err != nil {
+		return err
+	}
+
+	for _, e := range p.Removed {
+		o.Del(e.Key)
+	}
+	for _, c := range p.Changed {
+		o.Set(c.Key, c.Value)
+	}
+	if len(p.Added) == 0 && len(p.Moved) == 0 {
+		return nil
+	}
+
+	o.own()
+	o.compact()
+	n := len(o.entries) + len(p.Added)
+	entries := make([]entry[K, V], n)
+	placed := make([]bool, n)
+	moved := make(map[K]struct{}, len(p.Moved))
+	for _, mv := range p.Moved {
+		entries[mv.To] = o.entries[o.index[mv.Key]]
+		placed[mv.To] = true
+		moved[mv.Key] = struct{}{}
+	}
+	for _, e := range p.Added {
+		entries[e.Index] = entry[K, V]{key: e.Key, value: e.Value}
+		placed[e.Index] = true
+	}
+	// the other keys keep their relative order and fill the free slots
+	i := 0
+	for _, e := range o.entries {
+		if _, ok := moved[e.key]; ok {
+			continue
+		}
+		for placed[i] {
+			i++
+		}
+		entries[i] = e
+		i++
+	}
+
+	if o.index == nil { // zero value Map
+		o.index = make(map[K]int, n)
+	}
+	o.entries = entries
+	o.reindex(0, n)
+
+	if o.observers != nil {
+		for _, e := range p.Added {
+			o.notify(Event[K, V]{Kind: EventInserted, Key: e.Key, Value: e.Value, Index: e.Index})
+		}
+		if len(p.Moved) > 0 {
+			o.notifyReordered()
+		}
+	}
+	return nil
+}
+
+func (o *Map[K, V]) checkPatch(p *Patch[K, V]) error {
+	removed := make(map[K]struct{}, len(p.Removed))
+	for _, e := range p.Removed {
+		if !o.Contains(e.Key) {
+			return fmt.Errorf("%w: removed key %v not found", ErrPatchConflict, e.Key)
+		}
+		if _, ok := removed[e.Key]; ok {
+			return fmt.Errorf("%w: key %v removed twice", ErrPatchConflict, e.Key)
+		}
+		removed[e.Key] = struct{}{}
+	}
+
+	present := func(key K) bool {
+		_, ok := removed[key]
+		return !ok && o.Contains(key)
+	}
+	for _, c := range p.Changed {
+		if !present(c.Key) {
+			return fmt.Errorf("%w: changed key %v not found", ErrPatchConflict, c.Key)
+		}
+	}
+
+	n := o.Len() - len(p.Removed) + len(p.Added)
+	targets := make(map[int]struct{}, len(p.Added)+len(p.Moved))
+	checkTarget := func(i int) error {
+		if i < 0 || i >= n {
+			return fmt.Errorf("%w: index %d out of range with length %d", ErrPatchConflict, i, n)
+		}
+		if _, ok := targets[i]; ok {
+			return fmt.Errorf("%w: index %d used twice", ErrPatchConflict, i)
+		}
+		targets[i] = struct{}{}
+		return nil
+	}
+
+	added := make(map[K]struct{}, len(p.Added))
+	for _, e := range p.Added {
+		if _, ok := added[e.Key]; ok || present(e.Key) {
+			return fmt.Errorf("%w: added key %v already exists", ErrPatchConflict, e.Key)
+		}
+		added[e.Key] = struct{}{}
+		if err := checkTarget(e.Index); err != nil {
+			return err
+		}
+	}
+	moved := make(map[K]struct{}, len(p.Moved))
+	for _, mv := range p.Moved {
+		if !present(mv.Key) {
+			return fmt.Errorf("%w: moved key %v not found", ErrPatchConflict, mv.Key)
+		}
+		if _, ok := moved[mv.Key]; ok {
+			return fmt.Errorf("%w: key %v moved twice", ErrPatchConflict, mv.Key)
+		}
+		moved[mv.Key] = struct{}{}
+		if err := checkTarget(mv.To); err != nil {
+			return err
+		}
+	}
+
+	// the moves are relative to the order of a
+	if len(p.Removed) == 0 && len(p.Moved) == 0 {
+		return nil
+	}
+	from := make(map[K]int, len(p.Removed)+len(p.Moved))
+	for _, e := range p.Removed {
+		from[e.Key] = e.Index
+	}
+	for _, mv := range p.Moved {
+		from[mv.Key] = mv.From
+	}
+	i := 0
+	for key := range o.IterKeys {
+		if want, ok := from[key]; ok && want != i {
+			return fmt.Errorf("%w: key %v is at index %d instead of %d", ErrPatchConflict, key, i, want)
+		}
+		i++
+	}
+	return nil
+}
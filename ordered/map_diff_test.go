@@ -0,0 +1,167 @@
+package ordered
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newDiffTestMap(kvs ...any) *Map[string, int] {
+	om := NewMap[string, int]()
+	for i := 0; i < len(kvs); i += 2 {
+		om.Set(kvs[i].(string), kvs[i+1].(int))
+	}
+	return om
+}
+
+func TestDiff(t *testing.T) {
+	a := newDiffTestMap("a", 1, "b", 2, "c", 3, "d", 4, "e", 5)
+	b := newDiffTestMap("c", 3, "a", 1, "b", 20, "f", 6, "e", 5)
+
+	p := Diff(a, b)
+	require.Equal(t, []PatchEntry[string, int]{{Key: "d", Value: 4, Index: 3}}, p.Removed)
+	require.Equal(t, []PatchEntry[string, int]{{Key: "f", Value: 6, Index: 3}}, p.Added)
+	require.Equal(t, []PatchChange[string, int]{{Key: "b", OldValue: 2, Value: 20, Index: 2}}, p.Changed)
+	require.Equal(t, []PatchMove[string]{{Key: "c", From: 2, To: 0}}, p.Moved)
+	require.False(t, p.Empty())
+	require.Equal(t, ""+
+		"- [3] d: 4\n"+
+		"+ [3] f: 6\n"+
+		"~ [2] b: 2 -> 20\n"+
+		"> c: [2] -> [0]\n", p.String())
+
+	require.NoError(t, a.Apply(p))
+	require.Equal(t, b.Keys(), a.Keys())
+	require.Equal(t, b.Values(), a.Values())
+
+	t.Run("equal", func(t *testing.T) {
+		p := Diff(b, b.Clone())
+		require.True(t, p.Empty())
+		require.Empty(t, p.String())
+	})
+
+	t.Run("custom equality", func(t *testing.T) {
+		a := newDiffTestMap("a", 1, "b", 2)
+		b := newDiffTestMap("a", -1, "b", 3)
+		p := DiffFunc(a, b, func(x, y int) bool { return x*x == y*y })
+		require.Equal(t, []PatchChange[string, int]{{Key: "b", OldValue: 2, Value: 3, Index: 1}}, p.Changed)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data, err := json.Marshal(p)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"removed": [{"key": "d", "value": 4, "index": 3}],
+			"added": [{"key": "f", "value": 6, "index": 3}],
+			"changed": [{"key": "b", "old_value": 2, "value": 20, "index": 2}],
+			"moved": [{"key": "c", "from": 2, "to": 0}]
+		}`, string(data))
+
+		var decoded Patch[string, int]
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, p, &decoded)
+
+		data, err = json.Marshal(Diff(b, b))
+		require.NoError(t, err)
+		require.JSONEq(t, `{}`, string(data))
+	})
+}
+
+func TestDiff_Model(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	random := func() *Map[string, int] {
+		om := NewMap[string, int]()
+		for range r.IntN(30) {
+			om.Set(strconv.Itoa(r.IntN(40)), r.IntN(3))
+		}
+		for range r.IntN(5) {
+			if om.Len() > 1 {
+				k, _ := om.At(r.IntN(om.Len()))
+				om.MoveToFront(k)
+			}
+		}
+		return om
+	}
+
+	for range 500 {
+		a, b := random(), random()
+		p := Diff(a, b)
+
+		got := a.Clone()
+		got.InsertAt(0, "tmp", 0)
+		got.Del("tmp") // holes must not matter
+		require.NoError(t, got.Apply(p))
+		require.Equal(t, b.Keys(), got.Keys())
+		require.Equal(t, b.Values(), got.Values())
+		require.True(t, Diff(got, b).Empty())
+	}
+}
+
+func TestMap_ApplyConflict(t *testing.T) {
+	a := newDiffTestMap("a", 1, "b", 2, "c", 3)
+	tests := []struct {
+		name  string
+		patch Patch[string, int]
+	}{
+		{"removed missing", Patch[string, int]{Removed: []PatchEntry[string, int]{{Key: "x"}}}},
+		{"removed twice", Patch[string, int]{Removed: []PatchEntry[string, int]{{Key: "a"}, {Key: "a"}}}},
+		{"changed missing", Patch[string, int]{Changed: []PatchChange[string, int]{{Key: "x"}}}},
+		{"changed removed", Patch[string, int]{
+			Removed: []PatchEntry[string, int]{{Key: "a"}},
+			Changed: []PatchChange[string, int]{{Key: "a"}},
+		}},
+		{"added existing", Patch[string, int]{Added: []PatchEntry[string, int]{{Key: "a", Index: 0}}}},
+		{"added out of range", Patch[string, int]{Added: []PatchEntry[string, int]{{Key: "x", Index: 4}}}},
+		{"index used twice", Patch[string, int]{
+			Added: []PatchEntry[string, int]{{Key: "x", Index: 0}},
+			Moved: []PatchMove[string]{{Key: "c", To: 0}},
+		}},
+		{"moved missing", Patch[string, int]{Moved: []PatchMove[string]{{Key: "x"}}}},
+		{"moved out of range", Patch[string, int]{Moved: []PatchMove[string]{{Key: "a", To: -1}}}},
+		{"removed at another index", Patch[string, int]{Removed: []PatchEntry[string, int]{{Key: "b", Index: 0}}}},
+		{"moved from another index", Patch[string, int]{Moved: []PatchMove[string]{{Key: "c", From: 1, To: 0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			om := a.Clone()
+			require.ErrorIs(t, om.Apply(&tt.patch), ErrPatchConflict)
+			require.Equal(t, []string{"a", "b", "c"}, om.Keys())
+			require.Equal(t, []int{1, 2, 3}, om.Values())
+		})
+	}
+
+	t.Run("re-added key", func(t *testing.T) {
+		om := a.Clone()
+		require.NoError(t, om.Apply(&Patch[string, int]{
+			Removed: []PatchEntry[string, int]{{Key: "a"}},
+			Added:   []PatchEntry[string, int]{{Key: "a", Value: 10, Index: 2}},
+		}))
+		require.Equal(t, []string{"b", "c", "a"}, om.Keys())
+		require.Equal(t, []int{2, 3, 10}, om.Values())
+	})
+
+	t.Run("different order", func(t *testing.T) {
+		b := newDiffTestMap("c", 3, "a", 1)
+		om := newDiffTestMap("b", 2, "c", 3, "a", 1)
+		require.ErrorIs(t, om.Apply(Diff(a, b)), ErrPatchConflict)
+		require.Equal(t, []string{"b", "c", "a"}, om.Keys())
+	})
+}
+
+func TestMap_ApplyEvents(t *testing.T) {
+	a := newDiffTestMap("a", 1, "b", 2, "c", 3)
+	b := newDiffTestMap("c", 3, "b", 20, "d", 4)
+
+	var events []Event[string, int]
+	a.Observe(func(e Event[string, int]) { events = append(events, e) })
+	require.NoError(t, a.Apply(Diff(a, b)))
+	require.Equal(t, []Event[string, int]{
+		{Kind: EventDeleted, Key: "a", Value: 1},
+		{Kind: EventUpdated, Key: "b", Value: 20, OldValue: 2},
+		{Kind: EventInserted, Key: "d", Value: 4, Index: 2},
+		{Kind: EventReordered},
+	}, events)
+}
@@ -0,0 +1,507 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EncodeJSON writes the JSON encoding of the map to w, followed by a newline
+// like json.Encoder, see WithIndent, WithEscapeHTML, WithSortedKeys and WithFloatFormat.
+//
+// The output is written in chunks as it is produced, nested *Map and *Set values
+// and the values of []any and map[string]any are encoded recursively with
+// the same options instead of being buffered one by one. Other values are
+// encoded with encoding/json, so WithFloatFormat does not apply inside them.
+// If an error occurs, part of the output may already have been written.
+func (o *Map[K, V]) EncodeJSON(w io.Writer, opts ...Option) error {
+	if o == nil {
+		return ErrNilOrderedMap
+	}
+	bp := getJSONBuffer()
+	defer putJSONBuffer(bp)
+
+	e := getJSONEncoder(w, (*bp)[:0], opts)
+	defer putJSONEncoder(e)
+	err := o.encodeJSON(e, 0)
+	*bp = e.buf
+	if err != nil {
+		return err
+	}
+	e.buf = append(e.buf, '\n')
+	return e.flush()
+}
+
+// AppendJSON appends the JSON encoding of the map to dst and returns the extended buffer,
+// see WithIndent, WithEscapeHTML, WithSortedKeys and WithFloatFormat.
+//
+// Strings, booleans, numbers, nil, []byte, time.Time and nested *Map and *Set values
+// are encoded without reflection, other values are encoded with encoding/json.
+func (o *Map[K, V]) AppendJSON(dst []byte, opts ...Option) ([]byte, error) {
+	if o == nil {
+		return dst, ErrNilOrderedMap
+	}
+	e := getJSONEncoder(nil, dst, opts)
+	defer putJSONEncoder(e)
+	if err := o.encodeJSON(e, 0); err != nil {
+		return dst, err
+	}
+	return e.buf, nil
+}
+
+// appendJSON marshals v with the fast path into a pooled buffer and returns a copy.
+func appendJSON(v jsonStreamer) ([]byte, error) {
+	bp := getJSONBuffer()
+	defer putJSONBuffer(bp)
+
+	e := getJSONEncoder(nil, (*bp)[:0], nil)
+	defer putJSONEncoder(e)
+	e.rawTopKeys = true
+	err := v.encodeJSON(e, 0)
+	*bp = e.buf
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.buf), nil
+}
+
+// jsonStreamer is implemented by the containers that EncodeJSON encodes recursively.
+type jsonStreamer interface {
+	encodeJSON(e *jsonEncoder, depth int) error
+}
+
+const (
+	// jsonFlushSize is the size at which jsonEncoder writes its buffer out.
+	jsonFlushSize = 4096
+	// jsonMaxPooledSize is the largest buffer kept in the pools.
+	jsonMaxPooledSize = 64 << 10
+)
+
+var (
+	jsonBufferPool = sync.Pool{
+		New: func() any {
+			b := make([]byte, 0, 1024)
+			return &b
+		},
+	}
+	jsonEncoderPool = sync.Pool{
+		New: func() any {
+			e := &jsonEncoder{}
+			e.je = json.NewEncoder(&e.scratch)
+			return e
+		},
+	}
+)
+
+func getJSONBuffer() *[]byte {
+	return jsonBufferPool.Get().(*[]byte)
+}
+
+func putJSONBuffer(bp *[]byte) {
+	if cap(*bp) <= jsonMaxPooledSize {
+		jsonBufferPool.Put(bp)
+	}
+}
+
+type jsonEncoder struct {
+	buf        []byte
+	w          io.Writer // nil when appending
+	opt        option
+	indented   bool
+	escapeHTML bool
+	// rawTopKeys leaves <, > and & unescaped in the keys of the top-level object,
+	// like MarshalJSON always did, encoding/json escapes the nested ones.
+	rawTopKeys bool
+
+	// for the values encoded with encoding/json
+	scratch bytes.Buffer
+	je      *json.Encoder
+}
+
+func getJSONEncoder(w io.Writer, buf []byte, opts []Option) *jsonEncoder {
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	e.w, e.buf = w, buf
+	e.opt = option{}
+	for _, o := range opts {
+		o(&e.opt)
+	}
+	e.indented = e.opt.jsonPrefix != "" || e.opt.jsonIndent != ""
+	e.escapeHTML = !e.opt.jsonNoEscapeHTML
+	e.rawTopKeys = false
+	e.je.SetEscapeHTML(e.escapeHTML)
+	e.je.SetIndent("", "")
+	return e
+}
+
+func putJSONEncoder(e *jsonEncoder) {
+	e.w, e.buf = nil, nil
+	if e.scratch.Cap() <= jsonMaxPooledSize {
+		jsonEncoderPool.Put(e)
+	}
+}
+
+func (e *jsonEncoder) flush() error {
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+	return err
+}
+
+func (e *jsonEncoder) maybeFlush() error {
+	if e.w == nil || len(e.buf) < jsonFlushSize {
+		return nil
+	}
+	return e.flush()
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if !e.indented {
+		return
+	}
+	e.buf = append(e.buf, '\n')
+	e.buf = append(e.buf, e.opt.jsonPrefix...)
+	for range depth {
+		e.buf = append(e.buf, e.opt.jsonIndent...)
+	}
+}
+
+// member writes the i-th member of an object at depth.
+func member[K comparable, V any](e *jsonEncoder, i int, codec keyCodec, key K, value *V, depth int) error {
+	if err := beginMember(e, i, codec, key, depth); err != nil {
+		return err
+	}
+	if err := encodeValue(e, value, depth+1); err != nil {
+		return err
+	}
+	return e.maybeFlush()
+}
+
+// beginMember writes what precedes the value of the i-th member of an object at depth.
+func beginMember[K comparable](e *jsonEncoder, i int, codec keyCodec, key K, depth int) error {
+	if i > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	e.newline(depth + 1)
+	var err error
+	escapeHTML := e.escapeHTML && !(e.rawTopKeys && depth == 0)
+	if e.buf, err = appendKey(e.buf, codec, key, escapeHTML); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, ':')
+	if e.indented {
+		e.buf = append(e.buf, ' ')
+	}
+	return nil
+}
+
+// beginElement writes what precedes the i-th element of an array at depth.
+func (e *jsonEncoder) beginElement(i int, depth int) {
+	if i > 0 {
+		e.buf = append(e.buf, ',')
+	}
+	e.newline(depth + 1)
+}
+
+// end closes an object or array of n members or elements at depth.
+func (e *jsonEncoder) end(n int, depth int, c byte) {
+	if n > 0 {
+		e.newline(depth)
+	}
+	e.buf = append(e.buf, c)
+}
+
+// encodeValue encodes *v, using the fast path for the common types of V
+// without converting v to an interface.
+func encodeValue[V any](e *jsonEncoder, v *V, depth int) error {
+	switch p := any(v).(type) {
+	case *any:
+		return e.encode(*p, depth)
+	case *string:
+		e.buf = appendEscapedString(e.buf, *p, e.escapeHTML)
+	case *bool:
+		e.buf = strconv.AppendBool(e.buf, *p)
+	case *float64:
+		return e.float(*p, 64)
+	case *float32:
+		return e.float(float64(*p), 32)
+	case *int:
+		e.buf = strconv.AppendInt(e.buf, int64(*p), 10)
+	case *int64:
+		e.buf = strconv.AppendInt(e.buf, *p, 10)
+	case *int32:
+		e.buf = strconv.AppendInt(e.buf, int64(*p), 10)
+	case *uint:
+		e.buf = strconv.AppendUint(e.buf, uint64(*p), 10)
+	case *uint64:
+		e.buf = strconv.AppendUint(e.buf, *p, 10)
+	case *uint32:
+		e.buf = strconv.AppendUint(e.buf, uint64(*p), 10)
+	case *[]byte:
+		e.bytes(*p)
+	case *time.Time:
+		return e.time(*p, depth)
+	default:
+		return e.encode(*v, depth)
+	}
+	return nil
+}
+
+func (e *jsonEncoder) encode(v any, depth int) error {
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case jsonStreamer:
+		return v.encodeJSON(e, depth)
+	case string:
+		e.buf = appendEscapedString(e.buf, v, e.escapeHTML)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, v)
+	case float64:
+		return e.float(v, 64)
+	case float32:
+		return e.float(float64(v), 32)
+	case int:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int64:
+		e.buf = strconv.AppendInt(e.buf, v, 10)
+	case int32:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int16:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int8:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case uint:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint64:
+		e.buf = strconv.AppendUint(e.buf, v, 10)
+	case uint32:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint16:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case uint8:
+		e.buf = strconv.AppendUint(e.buf, uint64(v), 10)
+	case []byte:
+		e.bytes(v)
+	case time.Time:
+		return e.time(v, depth)
+	case []any:
+		if v == nil {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		e.buf = append(e.buf, '[')
+		for i := range v {
+			e.beginElement(i, depth)
+			if err := e.encode(v[i], depth+1); err != nil {
+				return err
+			}
+			if err := e.maybeFlush(); err != nil {
+				return err
+			}
+		}
+		e.end(len(v), depth, ']')
+	case map[string]any:
+		if v == nil {
+			e.buf = append(e.buf, "null"...)
+			return nil
+		}
+		// sorted like encoding/json
+		e.buf = append(e.buf, '{')
+		for i, key := range slices.Sorted(maps.Keys(v)) {
+			if err := beginMember(e, i, keyString, key, depth); err != nil {
+				return err
+			}
+			if err := e.encode(v[key], depth+1); err != nil {
+				return err
+			}
+			if err := e.maybeFlush(); err != nil {
+				return err
+			}
+		}
+		e.end(len(v), depth, '}')
+	default:
+		return e.encodeStd(v, depth)
+	}
+	return nil
+}
+
+// encodeStd encodes v with encoding/json.
+func (e *jsonEncoder) encodeStd(v any, depth int) error {
+	if e.indented {
+		e.je.SetIndent(e.opt.jsonPrefix+strings.Repeat(e.opt.jsonIndent, depth), e.opt.jsonIndent)
+	}
+	e.scratch.Reset()
+	if err := e.je.Encode(v); err != nil {
+		return err
+	}
+	e.buf = append(e.buf, e.scratch.Bytes()[:e.scratch.Len()-1]...) // without the trailing newline
+	return nil
+}
+
+// bytes encodes b as a base64 string like encoding/json.
+func (e *jsonEncoder) bytes(b []byte) {
+	if b == nil {
+		e.buf = append(e.buf, "null"...)
+		return
+	}
+	e.buf = append(e.buf, '"')
+	e.buf = base64.StdEncoding.AppendEncode(e.buf, b)
+	e.buf = append(e.buf, '"')
+}
+
+// time encodes t like time.Time.MarshalJSON.
+func (e *jsonEncoder) time(t time.Time, depth int) error {
+	if y := t.Year(); y < 0 || y > 9999 {
+		return e.encodeStd(t, depth) // for the error
+	}
+	e.buf = append(e.buf, '"')
+	e.buf = t.AppendFormat(e.buf, time.RFC3339Nano)
+	e.buf = append(e.buf, '"')
+	return nil
+}
+
+// float formats f like encoding/json, unless WithFloatFormat is set.
+func (e *jsonEncoder) float(f float64, bitSize int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return &json.UnsupportedValueError{
+			Value: reflect.ValueOf(f),
+			Str:   strconv.FormatFloat(f, 'g', -1, bitSize),
+		}
+	}
+
+	if e.opt.jsonFloatFormat != 0 {
+		e.buf = strconv.AppendFloat(e.buf, f, e.opt.jsonFloatFormat, e.opt.jsonFloatPrec, bitSize)
+		return nil
+	}
+
+	// from encoding/json: like ES6, use exponents only for very small and very large numbers
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bitSize == 64 && (abs < 1e-6 || abs >= 1e21) || bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	start := len(e.buf)
+	e.buf = strconv.AppendFloat(e.buf, f, format, -1, bitSize)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		b := e.buf[start:]
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			e.buf = e.buf[:len(e.buf)-1]
+		}
+	}
+	return nil
+}
+
+func (o *Map[K, V]) encodeJSON(e *jsonEncoder, depth int) error {
+	if o == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	return encodeObject(e, o.entries[o.first:], o.Len(), depth)
+}
+
+func (f *Frozen[K, V]) encodeJSON(e *jsonEncoder, depth int) error {
+	if f == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	if f.src != nil {
+		return f.src.encodeJSON(e, depth)
+	}
+	entries := make([]entry[K, V], 0, f.Len())
+	for key, value := range f.Iter {
+		entries = append(entries, entry[K, V]{key: key, value: value})
+	}
+	return encodeObject(e, entries, len(entries), depth)
+}
+
+// encodeObject encodes the n live entries of entries as a JSON object.
+//
+// It walks the slice rather than an iterator so that the loop bodies
+// do not escape to the heap.
+func encodeObject[K comparable, V any](e *jsonEncoder, entries []entry[K, V], n int, depth int) error {
+	codec := keyEncoderOf[K]()
+	if codec == keyUnsupported {
+		return ErrUnsupportedKeyType
+	}
+
+	e.buf = append(e.buf, '{')
+	if e.opt.jsonSortKeys {
+		members := make([]keyValue[string, V], 0, n)
+		for j := range entries {
+			if entries[j].deleted {
+				continue
+			}
+			s, err := encodeKey(codec, entries[j].key)
+			if err != nil {
+				return err
+			}
+			members = append(members, keyValue[string, V]{s, entries[j].value})
+		}
+		slices.SortStableFunc(members, func(a, b keyValue[string, V]) int {
+			return strings.Compare(a.key, b.key)
+		})
+		for i := range members {
+			if err := member(e, i, keyString, members[i].key, &members[i].value, depth); err != nil {
+				return err
+			}
+		}
+	} else {
+		i := 0
+		for j := range entries {
+			en := &entries[j]
+			if en.deleted {
+				continue
+			}
+			if err := member(e, i, codec, en.key, &en.value, depth); err != nil {
+				return err
+			}
+			i++
+		}
+	}
+	e.end(n, depth, '}')
+	return nil
+}
+
+func (s *Set[T]) encodeJSON(e *jsonEncoder, depth int) error {
+	if s == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	e.buf = append(e.buf, '[')
+	i := 0
+	for j := range s.m.entries {
+		en := &s.m.entries[j]
+		if en.deleted {
+			continue
+		}
+		e.beginElement(i, depth)
+		if err := encodeValue(e, &en.key, depth+1); err != nil {
+			return err
+		}
+		if err := e.maybeFlush(); err != nil {
+			return err
+		}
+		i++
+	}
+	e.end(i, depth, ']')
+	return nil
+}
+
+func (r *ReadOnlyMap[K, V]) encodeJSON(e *jsonEncoder, depth int) error {
+	if r == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	return r.m.encodeJSON(e, depth)
+}
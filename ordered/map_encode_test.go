@@ -0,0 +1,177 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const encodeTestDoc = `{"name":"app","version":1.5,"tiny":1e-7,"huge":1e+21,"enabled":true,"nothing":null,` +
+	`"tags":["b","a",{"z":1,"y":[]}],"server":{"port":8080,"hosts":[],"tls":{},"html":"<a&b>"}}`
+
+func TestMap_EncodeJSON(t *testing.T) {
+	om := newNestedTestMap(t, encodeTestDoc)
+
+	t.Run("compact", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, om.EncodeJSON(&buf))
+		want, err := om.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, string(want)+"\n", buf.String())
+	})
+
+	t.Run("indent", func(t *testing.T) {
+		for _, indent := range [][2]string{{"", "  "}, {"> ", "\t"}, {"#", ""}} {
+			var buf bytes.Buffer
+			require.NoError(t, om.EncodeJSON(&buf, WithIndent(indent[0], indent[1])))
+
+			data, err := om.MarshalJSON()
+			require.NoError(t, err)
+			var want bytes.Buffer
+			require.NoError(t, json.Indent(&want, data, indent[0], indent[1]))
+			require.Equal(t, want.String()+"\n", buf.String())
+		}
+	})
+
+	t.Run("escape html", func(t *testing.T) {
+		om := NewMap[string, any]()
+		om.Set("<k>", "<a&b>")
+		om.Set("t", struct{ S string }{"<&>"})
+
+		var buf bytes.Buffer
+		require.NoError(t, om.EncodeJSON(&buf))
+		require.Equal(t, `{"\u003ck\u003e":"\u003ca\u0026b\u003e","t":{"S":"\u003c\u0026\u003e"}}`+"\n", buf.String())
+
+		buf.Reset()
+		require.NoError(t, om.EncodeJSON(&buf, WithEscapeHTML(false)))
+		require.Equal(t, `{"<k>":"<a&b>","t":{"S":"<&>"}}`+"\n", buf.String())
+	})
+
+	t.Run("sorted keys", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, om.EncodeJSON(&buf, WithSortedKeys()))
+		require.Equal(t, `{"enabled":true,"huge":1e+21,"name":"app","nothing":null,`+
+			`"server":{"hosts":[],"html":"\u003ca\u0026b\u003e","port":8080,"tls":{}},`+
+			`"tags":["b","a",{"y":[],"z":1}],"tiny":1e-7,"version":1.5}`+"\n", buf.String())
+
+		ints := NewMap[int, int]()
+		ints.Set(10, 0)
+		ints.Set(9, 0)
+		buf.Reset()
+		require.NoError(t, ints.EncodeJSON(&buf, WithSortedKeys()))
+		require.Equal(t, `{"10":0,"9":0}`+"\n", buf.String())
+	})
+
+	t.Run("float format", func(t *testing.T) {
+		om := NewMap[string, any]()
+		om.Set("a", 1.0/3)
+		om.Set("b", []any{float32(2.5), 100.0})
+
+		var buf bytes.Buffer
+		require.NoError(t, om.EncodeJSON(&buf, WithFloatFormat('f', 2)))
+		require.Equal(t, `{"a":0.33,"b":[2.50,100.00]}`+"\n", buf.String())
+
+		buf.Reset()
+		require.NoError(t, om.EncodeJSON(&buf))
+		require.Equal(t, `{"a":0.3333333333333333,"b":[2.5,100]}`+"\n", buf.String())
+
+		om.Set("c", math.Inf(1))
+		var unsupported *json.UnsupportedValueError
+		require.ErrorAs(t, om.EncodeJSON(&buf), &unsupported)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		s := NewSet[int]()
+		s.Add(2)
+		s.Add(1)
+		nested := NewMap[int, string]()
+		nested.Set(1, "x")
+		var nilMap *Map[string, int]
+
+		om := NewMap[string, any]()
+		om.Set("set", s)
+		om.Set("nested", nested)
+		om.Set("readonly", nested.ReadOnly())
+		om.Set("nil", nilMap)
+		om.Set("ints", []any{int8(-1), uint16(2), int64(3), uint(4)})
+		om.Set("map", map[string]any{"b": 1, "a": []any{}})
+		om.Set("time", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+		om.Set("raw", json.RawMessage(`[1, 2]`))
+
+		var buf bytes.Buffer
+		require.NoError(t, om.EncodeJSON(&buf, WithIndent("", " ")))
+		want, err := json.MarshalIndent(om, "", " ")
+		require.NoError(t, err)
+		require.Equal(t, string(want)+"\n", buf.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		var nilMap *Map[string, int]
+		require.ErrorIs(t, nilMap.EncodeJSON(&buf), ErrNilOrderedMap)
+
+		bad := NewMap[struct{}, int]()
+		require.ErrorIs(t, bad.EncodeJSON(&buf), ErrUnsupportedKeyType)
+
+		om := NewMap[string, any]()
+		om.Set("f", func() {})
+		var unsupported *json.UnsupportedTypeError
+		require.ErrorAs(t, om.EncodeJSON(&buf), &unsupported)
+	})
+}
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestMap_EncodeJSONStreaming(t *testing.T) {
+	om := NewMap[string, any]()
+	for i := range 1000 {
+		nested := NewMap[string, any]()
+		nested.Set("i", i)
+		om.Set(strconv.Itoa(i), nested)
+	}
+
+	var w countingWriter
+	require.NoError(t, om.EncodeJSON(&w))
+	require.Greater(t, w.writes, 1)
+
+	want, err := om.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, string(want)+"\n", w.String())
+}
+
+func BenchmarkMap_EncodeJSON(b *testing.B) {
+	om := NewMap[string, any]()
+	for i := range 1000 {
+		nested := NewMap[string, any]()
+		nested.Set("name", "item"+strconv.Itoa(i))
+		nested.Set("value", float64(i)/3)
+		nested.Set("tags", []any{"a", "b"})
+		om.Set(strconv.Itoa(i), nested)
+	}
+
+	b.Run("MarshalJSON", func(b *testing.B) {
+		for b.Loop() {
+			_, _ = om.MarshalJSON()
+		}
+	})
+	b.Run("EncodeJSON", func(b *testing.B) {
+		var buf bytes.Buffer
+		for b.Loop() {
+			buf.Reset()
+			_ = om.EncodeJSON(&buf)
+		}
+	})
+}
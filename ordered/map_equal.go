@@ -0,0 +1,118 @@
+package ordered
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Equal reports whether a and b hold the same entries in the same order.
+func Equal[K, V comparable](a, b *Map[K, V]) bool {
+	return EqualFunc(a, b, func(x, y V) bool { return x == y })
+}
+
+// EqualFunc is like Equal but compares values with eq.
+func EqualFunc[K comparable, V1, V2 any](a *Map[K, V1], b *Map[K, V2], eq func(V1, V2) bool) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	j := b.first
+	for i := a.first; i < len(a.entries); i++ {
+		ea := &a.entries[i]
+		if ea.deleted {
+			continue
+		}
+		for b.entries[j].deleted {
+			j++
+		}
+		eb := &b.entries[j]
+		if ea.key != eb.key || !eq(ea.value, eb.value) {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
+// EqualUnordered reports whether a and b hold the same entries, in any order.
+func EqualUnordered[K, V comparable](a, b *Map[K, V]) bool {
+	return EqualUnorderedFunc(a, b, func(x, y V) bool { return x == y })
+}
+
+// EqualUnorderedFunc is like EqualUnordered but compares values with eq.
+func EqualUnorderedFunc[K comparable, V1, V2 any](a *Map[K, V1], b *Map[K, V2], eq func(V1, V2) bool) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	for k, va := range a.Iter {
+		vb, ok := b.TryGet(k)
+		if !ok || !eq(va, vb) {
+			return false
+		}
+	}
+	return true
+}
+
+// Mismatch returns a report of the differences between a and b, one per line,
+// or an empty string if they are Equal. See Patch.String for the format.
+func Mismatch[K, V comparable](a, b *Map[K, V]) string {
+	return Diff(a, b).String()
+}
+
+// MismatchFunc is like Mismatch but compares values with eq.
+func MismatchFunc[K comparable, V any](a, b *Map[K, V], eq func(x, y V) bool) string {
+	return DiffFunc(a, b, eq).String()
+}
+
+// MismatchUnordered is like Mismatch but ignores the order,
+// it returns an empty string if a and b are EqualUnordered.
+func MismatchUnordered[K, V comparable](a, b *Map[K, V]) string {
+	p := Diff(a, b)
+	p.Moved = nil
+	return p.String()
+}
+
+// Equal reports whether s and other hold the same elements in the same order.
+func (s *Set[T]) Equal(other *Set[T]) bool {
+	return EqualFunc(&s.m, &other.m, func(struct{}, struct{}) bool { return true })
+}
+
+// EqualUnordered reports whether s and other hold the same elements, in any order.
+func (s *Set[T]) EqualUnordered(other *Set[T]) bool {
+	if s.Len() != other.Len() {
+		return false
+	}
+	for v := range s.Iter {
+		if !other.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Mismatch returns a report of the differences between s and other, one per line,
+// or an empty string if they are Equal.
+func (s *Set[T]) Mismatch(other *Set[T]) string {
+	return setPatchString(Diff(&s.m, &other.m), true)
+}
+
+// MismatchUnordered is like Mismatch but ignores the order.
+func (s *Set[T]) MismatchUnordered(other *Set[T]) string {
+	return setPatchString(Diff(&s.m, &other.m), false)
+}
+
+// setPatchString formats p like Patch.String without the values.
+func setPatchString[T comparable](p *Patch[T, struct{}], moves bool) string {
+	var sb strings.Builder
+	for _, e := range p.Removed {
+		fmt.Fprintf(&sb, "- [%d] %v\n", e.Index, e.Key)
+	}
+	for _, e := range p.Added {
+		fmt.Fprintf(&sb, "+ [%d] %v\n", e.Index, e.Key)
+	}
+	if moves {
+		for _, mv := range p.Moved {
+			fmt.Fprintf(&sb, "> %v: [%d] -> [%d]\n", mv.Key, mv.From, mv.To)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,95 @@
+package ordered
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	a := newDiffTestMap("a", 1, "b", 2, "c", 3)
+
+	t.Run("ordered", func(t *testing.T) {
+		require.True(t, Equal(a, a))
+		require.True(t, Equal(a, a.Clone()))
+		require.False(t, Equal(a, newDiffTestMap("a", 1, "c", 3, "b", 2)))
+		require.False(t, Equal(a, newDiffTestMap("a", 1, "b", 2, "c", 4)))
+		require.False(t, Equal(a, newDiffTestMap("a", 1, "b", 2)))
+		require.True(t, Equal(NewMap[string, int](), &Map[string, int]{}))
+	})
+
+	t.Run("holes", func(t *testing.T) {
+		b := newDiffTestMap("x", 0, "a", 1, "y", 0, "b", 2, "c", 3, "z", 0)
+		b.Del("x")
+		b.Del("y")
+		c := newDiffTestMap("a", 1, "q", 0, "b", 2, "c", 3)
+		c.Del("q")
+		require.NotZero(t, c.holes)
+		require.False(t, Equal(a, b))
+		b.Del("z")
+		require.True(t, Equal(a, b))
+		require.True(t, Equal(b, c))
+		require.True(t, Equal(c, a))
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		b := newDiffTestMap("c", 3, "a", 1, "b", 2)
+		require.True(t, EqualUnordered(a, b))
+		require.False(t, EqualUnordered(a, newDiffTestMap("c", 3, "a", 1, "b", 20)))
+		require.False(t, EqualUnordered(a, newDiffTestMap("c", 3, "a", 1, "d", 2)))
+		require.False(t, EqualUnordered(a, newDiffTestMap("c", 3, "a", 1)))
+	})
+
+	t.Run("func", func(t *testing.T) {
+		b := NewMap[string, string]()
+		b.Set("a", "1")
+		b.Set("b", "2")
+		b.Set("c", "3")
+		eq := func(x int, y string) bool { return strconv.Itoa(x) == y }
+		require.True(t, EqualFunc(a, b, eq))
+		b.MoveToFront("c")
+		require.False(t, EqualFunc(a, b, eq))
+		require.True(t, EqualUnorderedFunc(a, b, eq))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		require.Empty(t, Mismatch(a, a.Clone()))
+		b := newDiffTestMap("c", 3, "a", 10, "d", 4)
+		require.Equal(t, ""+
+			"- [1] b: 2\n"+
+			"+ [2] d: 4\n"+
+			"~ [1] a: 1 -> 10\n"+
+			"> c: [2] -> [0]\n", Mismatch(a, b))
+		require.Equal(t, ""+
+			"- [1] b: 2\n"+
+			"+ [2] d: 4\n"+
+			"~ [1] a: 1 -> 10\n", MismatchUnordered(a, b))
+		require.Empty(t, MismatchUnordered(a, newDiffTestMap("c", 3, "b", 2, "a", 1)))
+		require.Equal(t, ""+
+			"- [1] b: 2\n"+
+			"+ [2] d: 4\n"+
+			"> c: [2] -> [0]\n", MismatchFunc(a, b, func(x, y int) bool { return true }))
+	})
+}
+
+func TestSet_Equal(t *testing.T) {
+	newSet := func(values ...string) *Set[string] {
+		s := NewSet[string]()
+		for _, v := range values {
+			s.Add(v)
+		}
+		return s
+	}
+	s := newSet("a", "b", "c")
+
+	require.True(t, s.Equal(newSet("a", "b", "c")))
+	require.False(t, s.Equal(newSet("b", "a", "c")))
+	require.False(t, s.Equal(newSet("a", "b")))
+	require.True(t, s.EqualUnordered(newSet("c", "a", "b")))
+	require.False(t, s.EqualUnordered(newSet("c", "a", "d")))
+
+	require.Empty(t, s.Mismatch(newSet("a", "b", "c")))
+	require.Equal(t, "- [0] a\n+ [2] d\n> c: [2] -> [0]\n", s.Mismatch(newSet("c", "b", "d")))
+	require.Equal(t, "- [0] a\n+ [2] d\n", s.MismatchUnordered(newSet("c", "b", "d")))
+}
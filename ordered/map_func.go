@@ -0,0 +1,136 @@
+package ordered
+
+import (
+	"iter"
+)
+
+// Filter collects the entries of seq for which keep returns true into a new Map.
+func Filter[K comparable, V any](seq iter.Seq2[K, V], keep func(key K, value V) bool) *Map[K, V] {
+	m := NewMap[K, V]()
+	for k, v := range seq {
+		if keep(k, v) {
+			m.Set(k, v)
+		}
+	}
+	return m
+}
+
+// MapValues collects the entries of seq into a new Map with their values transformed by fn.
+func MapValues[K comparable, V, W any](seq iter.Seq2[K, V], fn func(key K, value V) W) *Map[K, W] {
+	m := NewMap[K, W]()
+	for k, v := range seq {
+		m.Set(k, fn(k, v))
+	}
+	return m
+}
+
+// MapKeys collects the entries of seq into a new Map with their keys transformed by fn.
+//
+// Entries mapped to the same key are resolved by strategy, see Map.Merge.
+// With ErrorOnConflict, MapKeys returns an error wrapping ErrMergeConflict.
+func MapKeys[K, J comparable, V any](seq iter.Seq2[K, V], fn func(key K, value V) J, strategy MergeStrategy) (*Map[J, V], error) {
+	m := NewMap[J, V]()
+	for k, v := range seq {
+		if err := m.mergeEntry(fn(k, v), v, strategy); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Reduce folds the entries of seq in order into an accumulator starting with init.
+func Reduce[K, V, A any](seq iter.Seq2[K, V], init A, fn func(acc A, key K, value V) A) A {
+	acc := init
+	for k, v := range seq {
+		acc = fn(acc, k, v)
+	}
+	return acc
+}
+
+// Partition splits the entries of seq into the ones for which pred returns true and the others.
+func Partition[K comparable, V any](seq iter.Seq2[K, V], pred func(key K, value V) bool) (matched, rest *Map[K, V]) {
+	matched, rest = NewMap[K, V](), NewMap[K, V]()
+	for k, v := range seq {
+		if pred(k, v) {
+			matched.Set(k, v)
+		} else {
+			rest.Set(k, v)
+		}
+	}
+	return matched, rest
+}
+
+// GroupBy groups the entries of seq by the key returned by fn.
+// Groups are ordered by their first entry and keep the order of seq.
+func GroupBy[K, G comparable, V any](seq iter.Seq2[K, V], fn func(key K, value V) G) *Map[G, *Map[K, V]] {
+	groups := NewMap[G, *Map[K, V]]()
+	for k, v := range seq {
+		g := fn(k, v)
+		group, ok := groups.TryGet(g)
+		if !ok {
+			group = NewMap[K, V]()
+			groups.Set(g, group)
+		}
+		group.Set(k, v)
+	}
+	return groups
+}
+
+// FilterSet collects the values of seq for which keep returns true into a new Set.
+func FilterSet[T comparable](seq iter.Seq[T], keep func(value T) bool) *Set[T] {
+	s := NewSet[T]()
+	for v := range seq {
+		if keep(v) {
+			s.Add(v)
+		}
+	}
+	return s
+}
+
+// MapSet collects the values of seq transformed by fn into a new Set.
+// Values mapped to the same result keep the position of the first one.
+func MapSet[T, U comparable](seq iter.Seq[T], fn func(value T) U) *Set[U] {
+	s := NewSet[U]()
+	for v := range seq {
+		s.Add(fn(v))
+	}
+	return s
+}
+
+// ReduceSet folds the values of seq in order into an accumulator starting with init.
+func ReduceSet[T, A any](seq iter.Seq[T], init A, fn func(acc A, value T) A) A {
+	acc := init
+	for v := range seq {
+		acc = fn(acc, v)
+	}
+	return acc
+}
+
+// PartitionSet splits the values of seq into the ones for which pred returns true and the others.
+func PartitionSet[T comparable](seq iter.Seq[T], pred func(value T) bool) (matched, rest *Set[T]) {
+	matched, rest = NewSet[T](), NewSet[T]()
+	for v := range seq {
+		if pred(v) {
+			matched.Add(v)
+		} else {
+			rest.Add(v)
+		}
+	}
+	return matched, rest
+}
+
+// GroupSetBy groups the values of seq by the key returned by fn.
+// Groups are ordered by their first value and keep the order of seq.
+func GroupSetBy[T, G comparable](seq iter.Seq[T], fn func(value T) G) *Map[G, *Set[T]] {
+	groups := NewMap[G, *Set[T]]()
+	for v := range seq {
+		g := fn(v)
+		group, ok := groups.TryGet(g)
+		if !ok {
+			group = NewSet[T]()
+			groups.Set(g, group)
+		}
+		group.Add(v)
+	}
+	return groups
+}
@@ -0,0 +1,103 @@
+package ordered
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapFunc(t *testing.T) {
+	om := newDiffTestMap("b", 2, "a", 1, "d", 4, "c", 3, "e", 5)
+	even := func(_ string, v int) bool { return v%2 == 0 }
+
+	t.Run("Filter", func(t *testing.T) {
+		got := Filter(om.All(), even)
+		require.Equal(t, []string{"b", "d"}, got.Keys())
+		require.Equal(t, []int{2, 4}, got.Values())
+		require.Equal(t, 5, om.Len())
+
+		require.Zero(t, Filter(om.All(), func(string, int) bool { return false }).Len())
+	})
+
+	t.Run("MapValues", func(t *testing.T) {
+		got := MapValues(om.All(), func(k string, v int) string { return strings.Repeat(k, v) })
+		require.Equal(t, om.Keys(), got.Keys())
+		require.Equal(t, []string{"bb", "a", "dddd", "ccc", "eeeee"}, got.Values())
+	})
+
+	t.Run("MapKeys", func(t *testing.T) {
+		parity := func(_ string, v int) bool { return v%2 == 0 }
+
+		got, err := MapKeys(om.All(), parity, LastWins)
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, false}, got.Keys())
+		require.Equal(t, []int{4, 5}, got.Values())
+
+		got, err = MapKeys(om.All(), parity, LastWinsMoveToBack)
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, false}, got.Keys())
+
+		got, err = MapKeys(om.All(), parity, FirstWins)
+		require.NoError(t, err)
+		require.Equal(t, []int{2, 1}, got.Values())
+
+		_, err = MapKeys(om.All(), parity, ErrorOnConflict)
+		require.ErrorIs(t, err, ErrMergeConflict)
+
+		upper, err := MapKeys(om.All(), func(k string, _ int) string { return strings.ToUpper(k) }, ErrorOnConflict)
+		require.NoError(t, err)
+		require.Equal(t, []string{"B", "A", "D", "C", "E"}, upper.Keys())
+	})
+
+	t.Run("Reduce", func(t *testing.T) {
+		keys := Reduce(om.All(), "", func(acc string, k string, _ int) string { return acc + k })
+		require.Equal(t, "badce", keys)
+		sum := Reduce(om.Backward(), 0, func(acc int, _ string, v int) int { return acc + v })
+		require.Equal(t, 15, sum)
+	})
+
+	t.Run("Partition", func(t *testing.T) {
+		matched, rest := Partition(om.All(), even)
+		require.Equal(t, []string{"b", "d"}, matched.Keys())
+		require.Equal(t, []string{"a", "c", "e"}, rest.Keys())
+	})
+
+	t.Run("GroupBy", func(t *testing.T) {
+		groups := GroupBy(om.All(), func(_ string, v int) int { return v % 3 })
+		require.Equal(t, []int{2, 1, 0}, groups.Keys())
+		require.Equal(t, []string{"b", "e"}, groups.Get(2).Keys())
+		require.Equal(t, []string{"a", "d"}, groups.Get(1).Keys())
+		require.Equal(t, []int{3}, groups.Get(0).Values())
+	})
+
+	t.Run("other sources", func(t *testing.T) {
+		lru := NewLRU[string, int]()
+		lru.Set("x", 1)
+		lru.Set("y", 2)
+		require.Equal(t, []string{"y"}, Filter(lru.Iter, even).Keys())
+		require.Equal(t, []string{"b", "d"}, Filter(om.Freeze().All(), even).Keys())
+	})
+}
+
+func TestSetFunc(t *testing.T) {
+	s := NewSet[int]()
+	for _, v := range []int{5, 2, 8, 1, 4} {
+		s.Add(v)
+	}
+	even := func(v int) bool { return v%2 == 0 }
+
+	require.Equal(t, []int{2, 8, 4}, FilterSet(s.All(), even).Values())
+	require.Equal(t, []bool{false, true}, MapSet(s.All(), even).Values())
+	require.Equal(t, []int{10, 4, 16, 2, 8}, MapSet(s.All(), func(v int) int { return v * 2 }).Values())
+	require.Equal(t, 20, ReduceSet(s.All(), 0, func(acc, v int) int { return acc + v }))
+
+	matched, rest := PartitionSet(s.All(), even)
+	require.Equal(t, []int{2, 8, 4}, matched.Values())
+	require.Equal(t, []int{5, 1}, rest.Values())
+
+	groups := GroupSetBy(s.Backward(), func(v int) bool { return v > 3 })
+	require.Equal(t, []bool{true, false}, groups.Keys())
+	require.Equal(t, []int{4, 8, 5}, groups.Get(true).Values())
+	require.Equal(t, []int{1, 2}, groups.Get(false).Values())
+}
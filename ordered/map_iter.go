@@ -0,0 +1,126 @@
+package ordered
+
+import (
+	"iter"
+)
+
+// All returns an iterator over the entries in order.
+func (o *Map[K, V]) All() iter.Seq2[K, V] {
+	return o.Iter
+}
+
+// KeysSeq returns an iterator over the keys in order.
+func (o *Map[K, V]) KeysSeq() iter.Seq[K] {
+	return o.IterKeys
+}
+
+// ValuesSeq returns an iterator over the values in order.
+func (o *Map[K, V]) ValuesSeq() iter.Seq[V] {
+	return o.IterValues
+}
+
+// Backward returns an iterator over the entries in reverse order.
+func (o *Map[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for i := len(o.entries) - 1; i >= 0; i-- {
+			if i >= len(o.entries) { // yield may have removed trailing entries
+				continue
+			}
+			e := &o.entries[i]
+			if e.deleted {
+				continue
+			}
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// KeysView returns a read-only view of the keys of the map.
+//
+// The view reflects later changes of the map and does not copy the keys.
+func (o *Map[K, V]) KeysView() KeysView[K, V] {
+	return KeysView[K, V]{m: o}
+}
+
+// KeysView is a read-only view of the keys of a Map.
+type KeysView[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+func (v KeysView[K, V]) Len() int {
+	return v.m.Len()
+}
+
+// At returns the key at index i, see Map.At.
+func (v KeysView[K, V]) At(i int) K {
+	key, _ := v.m.At(i)
+	return key
+}
+
+func (v KeysView[K, V]) IndexOf(key K) int {
+	return v.m.IndexOf(key)
+}
+
+func (v KeysView[K, V]) Contains(key K) bool {
+	return v.m.Contains(key)
+}
+
+func (v KeysView[K, V]) All() iter.Seq[K] {
+	return v.m.IterKeys
+}
+
+func (v KeysView[K, V]) Backward() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range v.m.Backward() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Slice returns a copy of the keys.
+func (v KeysView[K, V]) Slice() []K {
+	return v.m.Keys()
+}
+
+// All returns an iterator over the elements in order.
+func (s *Set[T]) All() iter.Seq[T] {
+	return s.Iter
+}
+
+// Backward returns an iterator over the elements in reverse order.
+func (s *Set[T]) Backward() iter.Seq[T] {
+	return s.m.KeysView().Backward()
+}
+
+// Collect collects key-value pairs from seq into a new Map.
+// Duplicated keys keep their first position and their last value.
+func Collect[K comparable, V any](seq iter.Seq2[K, V], opts ...Option) *Map[K, V] {
+	m := NewMap[K, V](opts...)
+	Insert(m, seq)
+	return m
+}
+
+// Insert adds the key-value pairs from seq to m, see Map.Set.
+func Insert[K comparable, V any](m *Map[K, V], seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		m.Set(k, v)
+	}
+}
+
+// CollectSet collects values from seq into a new Set.
+func CollectSet[T comparable](seq iter.Seq[T], opts ...Option) *Set[T] {
+	s := NewSet[T](opts...)
+	InsertSet(s, seq)
+	return s
+}
+
+// InsertSet adds the values from seq to s, see Set.Add.
+func InsertSet[T comparable](s *Set[T], seq iter.Seq[T]) {
+	for v := range seq {
+		s.Add(v)
+	}
+}
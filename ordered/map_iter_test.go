@@ -0,0 +1,91 @@
+package ordered
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_Seq(t *testing.T) {
+	om := newPositionTestMap("c", "a", "d", "b")
+	om.Del("d")
+
+	require.Equal(t, []string{"c", "a", "b"}, slices.Collect(om.KeysSeq()))
+	require.Equal(t, []int{0, 1, 3}, slices.Collect(om.ValuesSeq()))
+	require.Equal(t, map[string]int{"c": 0, "a": 1, "b": 3}, maps.Collect(om.All()))
+
+	var keys []string
+	for k, v := range om.Backward() {
+		keys = append(keys, k)
+		require.Equal(t, om.Get(k), v)
+	}
+	require.Equal(t, []string{"b", "a", "c"}, keys)
+
+	t.Run("early break", func(t *testing.T) {
+		for k := range om.Backward() {
+			require.Equal(t, "b", k)
+			break
+		}
+	})
+
+	t.Run("delete while iterating backward", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c", "d")
+		var keys []string
+		for k := range om.Backward() {
+			keys = append(keys, k)
+			om.Del(k)
+		}
+		require.Equal(t, []string{"d", "c", "b", "a"}, keys)
+		require.Equal(t, 0, om.Len())
+	})
+}
+
+func TestOrderedMap_KeysView(t *testing.T) {
+	om := newPositionTestMap("c", "a")
+	view := om.KeysView()
+
+	require.Equal(t, 2, view.Len())
+	require.Equal(t, "a", view.At(1))
+	require.Equal(t, 0, view.IndexOf("c"))
+	require.True(t, view.Contains("a"))
+	require.Equal(t, []string{"c", "a"}, slices.Collect(view.All()))
+	require.Equal(t, []string{"a", "c"}, slices.Collect(view.Backward()))
+
+	// the view reflects later changes
+	om.Set("b", 2)
+	require.Equal(t, 3, view.Len())
+	require.Equal(t, []string{"c", "a", "b"}, slices.Collect(view.All()))
+
+	// the slice is a copy
+	keys := view.Slice()
+	slices.Sort(keys)
+	keys = append(keys, "z")
+	require.Equal(t, []string{"c", "a", "b"}, om.Keys())
+	require.False(t, om.Contains("z"))
+}
+
+func TestCollect(t *testing.T) {
+	om := Collect(slices.All([]string{"x", "y", "z"}))
+	require.Equal(t, []int{0, 1, 2}, om.Keys())
+	require.Equal(t, []string{"x", "y", "z"}, om.Values())
+
+	Insert(om, maps.All(map[int]string{1: "Y", 3: "w"}))
+	require.Equal(t, []int{0, 1, 2, 3}, om.Keys())
+	require.Equal(t, "Y", om.Get(1))
+
+	// round trip through the maps package
+	clone := Collect(om.All())
+	require.Equal(t, om.Keys(), clone.Keys())
+	require.Equal(t, om.Values(), clone.Values())
+}
+
+func TestCollectSet(t *testing.T) {
+	s := CollectSet(slices.Values([]int{3, 1, 3, 2}), WithCapacity(4))
+	require.Equal(t, []int{3, 1, 2}, slices.Collect(s.All()))
+	require.Equal(t, []int{2, 1, 3}, slices.Collect(s.Backward()))
+
+	InsertSet(s, slices.Values([]int{4, 1}))
+	require.Equal(t, []int{3, 1, 2, 4}, s.Values())
+}
@@ -7,51 +7,51 @@
 package ordered
 
 import (
-	"bytes"
 	"unicode/utf8"
 )
 
-func writeEscapedString(buf *bytes.Buffer, s string) {
-	buf.WriteByte('"')
+// appendEscapedString appends s as a JSON string to dst, escapeHTML also escapes <, > and &.
+func appendEscapedString(dst []byte, s string, escapeHTML bool) []byte {
+	dst = append(dst, '"')
 	start := 0
 	for i := 0; i < len(s); {
 		if b := s[i]; b < utf8.RuneSelf {
-			if htmlSafeSet[b] || safeSet[b] {
+			if escapeHTML && htmlSafeSet[b] || !escapeHTML && safeSet[b] {
 				i++
 				continue
 			}
-			buf.WriteString(s[start:i])
+			dst = append(dst, s[start:i]...)
 			switch b {
 			case '\\', '"':
-				buf.WriteByte('\\')
-				buf.WriteByte(b)
+				dst = append(dst, '\\')
+				dst = append(dst, b)
 			case '\b':
-				buf.WriteByte('\\')
-				buf.WriteByte('b')
+				dst = append(dst, '\\')
+				dst = append(dst, 'b')
 			case '\f':
-				buf.WriteByte('\\')
-				buf.WriteByte('f')
+				dst = append(dst, '\\')
+				dst = append(dst, 'f')
 			case '\n':
-				buf.WriteByte('\\')
-				buf.WriteByte('n')
+				dst = append(dst, '\\')
+				dst = append(dst, 'n')
 			case '\r':
-				buf.WriteByte('\\')
-				buf.WriteByte('r')
+				dst = append(dst, '\\')
+				dst = append(dst, 'r')
 			case '\t':
-				buf.WriteByte('\\')
-				buf.WriteByte('t')
+				dst = append(dst, '\\')
+				dst = append(dst, 't')
 			default:
 				// This encodes bytes < 0x20 except for \b, \f, \n, \r and \t.
 				// If escapeHTML is set, it also escapes <, >, and &
 				// because they can lead to security holes when
 				// user-controlled strings are rendered into JSON
 				// and served to some browsers.
-				buf.WriteByte('\\')
-				buf.WriteByte('u')
-				buf.WriteByte('0')
-				buf.WriteByte('0')
-				buf.WriteByte(hex[b>>4])
-				buf.WriteByte(hex[b&0xF])
+				dst = append(dst, '\\')
+				dst = append(dst, 'u')
+				dst = append(dst, '0')
+				dst = append(dst, '0')
+				dst = append(dst, hex[b>>4])
+				dst = append(dst, hex[b&0xF])
 			}
 			i++
 			start = i
@@ -64,8 +64,8 @@ func writeEscapedString(buf *bytes.Buffer, s string) {
 		n := min(len(s)-i, utf8.UTFMax)
 		c, size := utf8.DecodeRuneInString(s[i : i+n])
 		if c == utf8.RuneError && size == 1 {
-			buf.WriteString(s[start:i])
-			buf.WriteString(`\ufffd`)
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, `\ufffd`...)
 			i += size
 			start = i
 			continue
@@ -78,17 +78,17 @@ func writeEscapedString(buf *bytes.Buffer, s string) {
 		// escape them, so we do so unconditionally.
 		// See https://en.wikipedia.org/wiki/JSON#Safety.
 		if c == '\u2028' || c == '\u2029' {
-			buf.WriteString(s[start:i])
-			buf.WriteString(`\u202`)
-			buf.WriteByte(hex[c&0xF])
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, `\u202`...)
+			dst = append(dst, hex[c&0xF])
 			i += size
 			start = i
 			continue
 		}
 		i += size
 	}
-	buf.WriteString(s[start:])
-	buf.WriteByte('"')
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
 }
 
 // safeSet holds the value true if the ASCII character with the given array
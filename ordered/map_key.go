@@ -0,0 +1,134 @@
+package ordered
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// keyCodec tells how keys of a type are converted to and from JSON object keys,
+// following the same rules as encoding/json does for map keys.
+type keyCodec uint8
+
+const (
+	keyUnsupported keyCodec = iota
+	keyString               // string kinds are used directly
+	keyText                 // encoding.TextMarshaler / encoding.TextUnmarshaler
+	keyInt                  // signed integers, in base 10
+	keyUint                 // unsigned integers, in base 10
+)
+
+var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// keyEncoderOf returns the codec used to encode keys of type K.
+//
+// Like encoding/json, string kinds take precedence over encoding.TextMarshaler.
+func keyEncoderOf[K comparable]() keyCodec {
+	t := reflect.TypeFor[K]()
+	switch {
+	case t.Kind() == reflect.String:
+		return keyString
+	case t.Implements(textMarshalerType):
+		return keyText
+	}
+	return intKeyCodecOf(t)
+}
+
+// keyDecoderOf returns the codec used to decode keys of type K.
+//
+// Like encoding/json, encoding.TextUnmarshaler takes precedence over string kinds.
+func keyDecoderOf[K comparable]() keyCodec {
+	t := reflect.TypeFor[K]()
+	switch {
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return keyText
+	case t.Kind() == reflect.String:
+		return keyString
+	}
+	return intKeyCodecOf(t)
+}
+
+func intKeyCodecOf(t reflect.Type) keyCodec {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return keyInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return keyUint
+	default:
+		return keyUnsupported
+	}
+}
+
+func encodeKey[K comparable](codec keyCodec, key K) (string, error) {
+	switch codec {
+	case keyString:
+		// K is a string kind, it can be converted directly to avoid unnecessary allocation
+		return *(*string)(unsafe.Pointer(&key)), nil
+	case keyText:
+		if v := reflect.ValueOf(&key).Elem(); v.Kind() == reflect.Pointer && v.IsNil() {
+			return "", nil
+		}
+		text, err := any(key).(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", &json.MarshalerError{Type: reflect.TypeFor[K](), Err: err}
+		}
+		return string(text), nil
+	case keyInt:
+		return strconv.FormatInt(reflect.ValueOf(key).Int(), 10), nil
+	case keyUint:
+		return strconv.FormatUint(reflect.ValueOf(key).Uint(), 10), nil
+	default:
+		return "", ErrUnsupportedKeyType
+	}
+}
+
+// appendKey appends key as a JSON string to dst, integers are formatted in place.
+func appendKey[K comparable](dst []byte, codec keyCodec, key K, escapeHTML bool) ([]byte, error) {
+	switch codec {
+	case keyInt:
+		dst = append(dst, '"')
+		dst = strconv.AppendInt(dst, reflect.ValueOf(&key).Elem().Int(), 10)
+		return append(dst, '"'), nil
+	case keyUint:
+		dst = append(dst, '"')
+		dst = strconv.AppendUint(dst, reflect.ValueOf(&key).Elem().Uint(), 10)
+		return append(dst, '"'), nil
+	}
+	s, err := encodeKey(codec, key)
+	if err != nil {
+		return dst, err
+	}
+	return appendEscapedString(dst, s, escapeHTML), nil
+}
+
+func decodeKey[K comparable](codec keyCodec, s string) (key K, err error) {
+	switch codec {
+	case keyString:
+		// K is a string kind, it's safe to assign it directly
+		*(*string)(unsafe.Pointer(&key)) = s
+	case keyText:
+		err = any(&key).(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	case keyInt:
+		v := reflect.ValueOf(&key).Elem()
+		n, perr := strconv.ParseInt(s, 10, 64)
+		if perr != nil || v.OverflowInt(n) {
+			return key, &json.UnmarshalTypeError{Value: "number " + s, Type: v.Type()}
+		}
+		v.SetInt(n)
+	case keyUint:
+		v := reflect.ValueOf(&key).Elem()
+		n, perr := strconv.ParseUint(s, 10, 64)
+		if perr != nil || v.OverflowUint(n) {
+			return key, &json.UnmarshalTypeError{Value: "number " + s, Type: v.Type()}
+		}
+		v.SetUint(n)
+	default:
+		err = ErrUnsupportedKeyType
+	}
+	return key, err
+}
@@ -0,0 +1,253 @@
+package ordered
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MergeStrategy decides what happens when a merged key already exists.
+type MergeStrategy uint8
+
+const (
+	LastWins           MergeStrategy = iota // replace the value, keep the existing position
+	LastWinsMoveToBack                      // replace the value and move the key to the back
+	FirstWins                               // keep the existing value
+	ErrorOnConflict                         // fail with ErrMergeConflict
+)
+
+// SliceMergeMode decides how DeepMerge merges two []any values.
+type SliceMergeMode uint8
+
+const (
+	SliceReplace    SliceMergeMode = iota // treat slices like other values, following the MergeStrategy
+	SliceAppend                           // append the merged slice to the existing one
+	SliceMergeIndex                       // deep merge the elements at the same index, appending the extra ones
+)
+
+var ErrMergeConflict = errors.New("merge conflict")
+
+// Merge merges other into o in place, following strategy for the keys present in both.
+// New keys are appended in the order of other.
+//
+// With ErrorOnConflict, o is left unchanged if any key conflicts.
+func (o *Map[K, V]) Merge(other *Map[K, V], strategy MergeStrategy) error {
+	if strategy == ErrorOnConflict {
+		for key := range other.Iter {
+			if o.Contains(key) {
+				return conflictError(key)
+			}
+		}
+	}
+
+	for key, value := range other.Iter {
+		o.mergeEntry(key, value, strategy)
+	}
+	return nil
+}
+
+// mergeEntry sets key to value following strategy,
+// it returns an error if key exists and strategy is ErrorOnConflict.
+func (o *Map[K, V]) mergeEntry(key K, value V, strategy MergeStrategy) error {
+	if !o.Contains(key) {
+		o.Set(key, value)
+		return nil
+	}
+	switch strategy {
+	case LastWins:
+		o.Set(key, value)
+	case LastWinsMoveToBack:
+		o.Set(key, value)
+		o.MoveToBack(key)
+	case ErrorOnConflict:
+		return conflictError(key)
+	}
+	return nil
+}
+
+// MergeFunc merges other into o in place, replacing the value of the keys present in
+// both by the result of resolve, their position is kept.
+// New keys are appended in the order of other.
+//
+// If resolve returns an error, MergeFunc returns it and o is left unchanged.
+func (o *Map[K, V]) MergeFunc(other *Map[K, V], resolve func(key K, existing, incoming V) (V, error)) error {
+	resolved := make([]keyValue[K, V], 0, other.Len())
+	for key, value := range other.Iter {
+		if existing, ok := o.TryGet(key); ok {
+			var err error
+			if value, err = resolve(key, existing, value); err != nil {
+				return err
+			}
+		}
+		resolved = append(resolved, keyValue[K, V]{key, value})
+	}
+
+	for _, kv := range resolved {
+		o.Set(kv.key, kv.value)
+	}
+	return nil
+}
+
+// MapMergeWith merges the given ordered maps into a new ordered map,
+// following strategy for the keys present in more than one of them.
+func MapMergeWith[K comparable, V any](strategy MergeStrategy, m ...*Map[K, V]) (*Map[K, V], error) {
+	totalSize := 0
+	for _, om := range m {
+		totalSize += om.Len()
+	}
+
+	merged := NewMap[K, V](WithCapacity(totalSize))
+	for _, om := range m {
+		if err := merged.Merge(om, strategy); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// DeepMerge merges src into dst in place.
+//
+// Nested *Map[K, any] values present in both are merged recursively, []any values
+// as set by WithSliceMerge, and other conflicts are resolved by WithMergeStrategy.
+// Existing keys keep their position unless the strategy is LastWinsMoveToBack
+// and new keys are appended in the order of src.
+//
+// Values taken from src are deep copies, so dst never shares nested maps or slices
+// with it. With ErrorOnConflict, dst is left unchanged if any value conflicts.
+func DeepMerge[K comparable](dst, src *Map[K, any], opts ...Option) error {
+	var opt option
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	if opt.mergeStrategy == ErrorOnConflict {
+		if err := checkDeepMerge[K](dst, src, opt, nil); err != nil {
+			return err
+		}
+	}
+	deepMergeMap(dst, src, opt)
+	return nil
+}
+
+func deepMergeMap[K comparable](dst, src *Map[K, any], opt option) {
+	for key, value := range src.Iter {
+		existing, ok := dst.TryGet(key)
+		if !ok {
+			dst.Set(key, deepCloneAny[K](value))
+			continue
+		}
+		if merged, ok := deepMergeValue[K](existing, value, opt); ok {
+			dst.Set(key, merged)
+			continue
+		}
+		switch opt.mergeStrategy {
+		case LastWins:
+			dst.Set(key, deepCloneAny[K](value))
+		case LastWinsMoveToBack:
+			dst.Set(key, deepCloneAny[K](value))
+			dst.MoveToBack(key)
+		}
+	}
+}
+
+// deepMergeValue merges two nested maps, or slices if the slice mode allows it.
+// It reports false if the values must be resolved by the merge strategy.
+func deepMergeValue[K comparable](dst, src any, opt option) (any, bool) {
+	switch dst := dst.(type) {
+	case *Map[K, any]:
+		src, ok := src.(*Map[K, any])
+		if !ok {
+			return nil, false
+		}
+		deepMergeMap(dst, src, opt)
+		return dst, true
+	case []any:
+		src, ok := src.([]any)
+		if !ok {
+			return nil, false
+		}
+		switch opt.sliceMerge {
+		case SliceAppend:
+			merged := slices.Clip(dst)
+			for _, v := range src {
+				merged = append(merged, deepCloneAny[K](v))
+			}
+			return merged, true
+		case SliceMergeIndex:
+			merged := slices.Clone(dst)
+			for i, v := range src {
+				switch {
+				case i >= len(merged):
+					merged = append(merged, deepCloneAny[K](v))
+				default:
+					if m, ok := deepMergeValue[K](merged[i], v, opt); ok {
+						merged[i] = m
+					} else if opt.mergeStrategy != FirstWins {
+						merged[i] = deepCloneAny[K](v)
+					}
+				}
+			}
+			return merged, true
+		}
+	}
+	return nil, false
+}
+
+// checkDeepMerge returns an error for the first conflict deepMergeMap would resolve.
+func checkDeepMerge[K comparable](dst, src any, opt option, path []any) error {
+	switch dst := dst.(type) {
+	case *Map[K, any]:
+		if src, ok := src.(*Map[K, any]); ok {
+			for key, value := range src.Iter {
+				if existing, ok := dst.TryGet(key); ok {
+					if err := checkDeepMerge[K](existing, value, opt, append(path, key)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+	case []any:
+		if src, ok := src.([]any); ok && opt.sliceMerge != SliceReplace {
+			if opt.sliceMerge == SliceMergeIndex {
+				for i := range min(len(dst), len(src)) {
+					if err := checkDeepMerge[K](dst[i], src[i], opt, append(path, i)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+	}
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = fmt.Sprint(key)
+	}
+	return conflictError(strings.Join(keys, "."))
+}
+
+func conflictError[K comparable](key K) error {
+	return fmt.Errorf("%w: key %v", ErrMergeConflict, key)
+}
+
+// deepCloneAny copies nested *Map[K, any] and []any values.
+func deepCloneAny[K comparable](v any) any {
+	switch v := v.(type) {
+	case *Map[K, any]:
+		m := NewMap[K, any](WithCapacity(v.Len()))
+		m.nestedMaps = v.nestedMaps
+		for key, child := range v.Iter {
+			m.Set(key, deepCloneAny[K](child))
+		}
+		return m
+	case []any:
+		arr := make([]any, len(v))
+		for i, child := range v {
+			arr[i] = deepCloneAny[K](child)
+		}
+		return arr
+	default:
+		return v
+	}
+}
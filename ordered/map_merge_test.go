@@ -0,0 +1,168 @@
+package ordered
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap_Merge(t *testing.T) {
+	newMaps := func() (*Map[string, int], *Map[string, int]) {
+		return newDiffTestMap("a", 1, "b", 2, "c", 3), newDiffTestMap("d", 4, "b", 20, "a", 10)
+	}
+
+	tests := []struct {
+		strategy   MergeStrategy
+		wantKeys   []string
+		wantValues []int
+	}{
+		{LastWins, []string{"a", "b", "c", "d"}, []int{10, 20, 3, 4}},
+		{LastWinsMoveToBack, []string{"c", "d", "b", "a"}, []int{3, 4, 20, 10}},
+		{FirstWins, []string{"a", "b", "c", "d"}, []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		om, other := newMaps()
+		require.NoError(t, om.Merge(other, tt.strategy))
+		require.Equal(t, tt.wantKeys, om.Keys())
+		require.Equal(t, tt.wantValues, om.Values())
+		require.Equal(t, []string{"d", "b", "a"}, other.Keys())
+	}
+
+	t.Run("error on conflict", func(t *testing.T) {
+		om, other := newMaps()
+		err := om.Merge(other, ErrorOnConflict)
+		require.ErrorIs(t, err, ErrMergeConflict)
+		require.EqualError(t, err, "merge conflict: key b")
+		require.Equal(t, []string{"a", "b", "c"}, om.Keys())
+
+		require.NoError(t, om.Merge(newDiffTestMap("x", 0), ErrorOnConflict))
+		require.Equal(t, []string{"a", "b", "c", "x"}, om.Keys())
+	})
+
+	t.Run("func", func(t *testing.T) {
+		om, other := newMaps()
+		var conflicts []string
+		require.NoError(t, om.MergeFunc(other, func(key string, existing, incoming int) (int, error) {
+			conflicts = append(conflicts, key)
+			return existing + incoming, nil
+		}))
+		require.Equal(t, []string{"b", "a"}, conflicts)
+		require.Equal(t, []string{"a", "b", "c", "d"}, om.Keys())
+		require.Equal(t, []int{11, 22, 3, 4}, om.Values())
+
+		errBoom := errors.New("boom")
+		om, other = newMaps()
+		require.ErrorIs(t, om.MergeFunc(other, func(key string, existing, incoming int) (int, error) {
+			if key == "a" {
+				return 0, errBoom
+			}
+			return incoming, nil
+		}), errBoom)
+		require.Equal(t, []string{"a", "b", "c"}, om.Keys())
+		require.Equal(t, []int{1, 2, 3}, om.Values())
+	})
+
+	t.Run("MapMergeWith", func(t *testing.T) {
+		a, b := newMaps()
+		c := newDiffTestMap("c", 30)
+
+		merged, err := MapMergeWith(LastWinsMoveToBack, a, b, c)
+		require.NoError(t, err)
+		require.Equal(t, []string{"d", "b", "a", "c"}, merged.Keys())
+		require.Equal(t, []int{4, 20, 10, 30}, merged.Values())
+		require.Equal(t, []int{1, 2, 3}, a.Values())
+
+		_, err = MapMergeWith(ErrorOnConflict, a, c)
+		require.ErrorIs(t, err, ErrMergeConflict)
+
+		merged, err = MapMergeWith[string, int](ErrorOnConflict)
+		require.NoError(t, err)
+		require.Zero(t, merged.Len())
+	})
+}
+
+func TestDeepMerge(t *testing.T) {
+	const base = `{"name":"app","server":{"host":"localhost","port":80,"tls":{"enabled":false}},"tags":["a","b"],"plugins":[{"name":"x","on":true}]}`
+	const layer = `{"server":{"port":8080,"tls":{"cert":"c.pem"},"timeout":30},"tags":["c"],"plugins":[{"on":false},{"name":"y"}],"debug":true}`
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			"defaults",
+			nil,
+			`{"name":"app","server":{"host":"localhost","port":8080,"tls":{"enabled":false,"cert":"c.pem"},"timeout":30},"tags":["c"],"plugins":[{"on":false},{"name":"y"}],"debug":true}`,
+		},
+		{
+			"slice append",
+			[]Option{WithSliceMerge(SliceAppend)},
+			`{"name":"app","server":{"host":"localhost","port":8080,"tls":{"enabled":false,"cert":"c.pem"},"timeout":30},"tags":["a","b","c"],"plugins":[{"name":"x","on":true},{"on":false},{"name":"y"}],"debug":true}`,
+		},
+		{
+			"slice merge index",
+			[]Option{WithSliceMerge(SliceMergeIndex)},
+			`{"name":"app","server":{"host":"localhost","port":8080,"tls":{"enabled":false,"cert":"c.pem"},"timeout":30},"tags":["c","b"],"plugins":[{"name":"x","on":false},{"name":"y"}],"debug":true}`,
+		},
+		{
+			"first wins",
+			[]Option{WithMergeStrategy(FirstWins), WithSliceMerge(SliceMergeIndex)},
+			`{"name":"app","server":{"host":"localhost","port":80,"tls":{"enabled":false,"cert":"c.pem"},"timeout":30},"tags":["a","b"],"plugins":[{"name":"x","on":true},{"name":"y"}],"debug":true}`,
+		},
+		{
+			"move to back",
+			[]Option{WithMergeStrategy(LastWinsMoveToBack)},
+			`{"name":"app","server":{"host":"localhost","tls":{"enabled":false,"cert":"c.pem"},"port":8080,"timeout":30},"tags":["c"],"plugins":[{"on":false},{"name":"y"}],"debug":true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := newNestedTestMap(t, base)
+			src := newNestedTestMap(t, layer)
+			require.NoError(t, DeepMerge(dst, src, tt.opts...))
+			requireJSON(t, tt.want, dst)
+			requireJSON(t, layer, src)
+		})
+	}
+
+	t.Run("no sharing with src", func(t *testing.T) {
+		dst := NewMap[string, any]()
+		src := newNestedTestMap(t, layer)
+		require.NoError(t, DeepMerge(dst, src))
+		requireJSON(t, layer, dst)
+
+		dst.Get("server").(*Map[string, any]).Set("port", 1)
+		dst.Get("tags").([]any)[0] = "z"
+		requireJSON(t, layer, src)
+	})
+
+	t.Run("error on conflict", func(t *testing.T) {
+		dst := newNestedTestMap(t, base)
+		src := newNestedTestMap(t, layer)
+		err := DeepMerge(dst, src, WithMergeStrategy(ErrorOnConflict))
+		require.ErrorIs(t, err, ErrMergeConflict)
+		require.EqualError(t, err, "merge conflict: key server.port")
+		requireJSON(t, base, dst)
+
+		err = DeepMerge(dst, newNestedTestMap(t, `{"plugins":[{"on":false}]}`), WithMergeStrategy(ErrorOnConflict), WithSliceMerge(SliceMergeIndex))
+		require.EqualError(t, err, "merge conflict: key plugins.0.on")
+
+		err = DeepMerge(dst, newNestedTestMap(t, `{"tags":["c"]}`), WithMergeStrategy(ErrorOnConflict))
+		require.EqualError(t, err, "merge conflict: key tags")
+		requireJSON(t, base, dst)
+
+		require.NoError(t, DeepMerge(dst, newNestedTestMap(t, `{"server":{"tls":{"cert":"c.pem"}},"tags":["c"]}`),
+			WithMergeStrategy(ErrorOnConflict), WithSliceMerge(SliceAppend)))
+		requireJSON(t, `{"name":"app","server":{"host":"localhost","port":80,"tls":{"enabled":false,"cert":"c.pem"}},"tags":["a","b","c"],"plugins":[{"name":"x","on":true}]}`, dst)
+	})
+
+	t.Run("layers", func(t *testing.T) {
+		merged := NewMap[string, any]()
+		for _, layer := range []string{`{"a":{"x":1}}`, `{"b":2,"a":{"y":2}}`, `{"a":{"x":3}}`} {
+			require.NoError(t, DeepMerge(merged, newNestedTestMap(t, layer)))
+		}
+		requireJSON(t, `{"a":{"x":3,"y":2},"b":2}`, merged)
+	})
+}
@@ -0,0 +1,97 @@
+package ordered
+
+import (
+	"slices"
+)
+
+// EventKind is the kind of change reported to Map observers.
+type EventKind uint8
+
+const (
+	EventInserted  EventKind = iota + 1 // a new key was added
+	EventUpdated                        // the value of an existing key was replaced
+	EventDeleted                        // a key was removed
+	EventCleared                        // all keys were removed
+	EventReordered                      // the order of existing keys changed
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventInserted:
+		return "inserted"
+	case EventUpdated:
+		return "updated"
+	case EventDeleted:
+		return "deleted"
+	case EventCleared:
+		return "cleared"
+	case EventReordered:
+		return "reordered"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change of a Map.
+type Event[K comparable, V any] struct {
+	Kind EventKind
+	// Key is the changed key, it is the zero value for EventCleared and EventReordered.
+	Key K
+	// Value is the new value for EventInserted and EventUpdated,
+	// and the removed value for EventDeleted.
+	Value V
+	// OldValue is the replaced value for EventUpdated.
+	OldValue V
+	// Index is the position of the new key for EventInserted.
+	Index int
+}
+
+type observer[K comparable, V any] struct {
+	id int
+	fn func(Event[K, V])
+}
+
+type observers[K comparable, V any] struct {
+	nextID int
+	list   []observer[K, V] // replaced on every change, so notify can iterate it safely
+}
+
+// Observe registers fn to be called synchronously after every change of the map
+// and returns a function that unregisters it.
+//
+// Observers are called in registration order. fn may modify the map, it will be
+// notified recursively. Clones of the map do not inherit the observers.
+func (o *Map[K, V]) Observe(fn func(Event[K, V])) (unsubscribe func()) {
+	if o.observers == nil {
+		o.observers = &observers[K, V]{}
+	}
+	obs := o.observers
+	id := obs.nextID
+	obs.nextID++
+	obs.list = append(slices.Clip(obs.list), observer[K, V]{id, fn})
+
+	return func() {
+		i := slices.IndexFunc(obs.list, func(ob observer[K, V]) bool {
+			return ob.id == id
+		})
+		if i == -1 {
+			return
+		}
+		obs.list = slices.Delete(slices.Clone(obs.list), i, i+1)
+		if len(obs.list) == 0 && o.observers == obs {
+			o.observers = nil
+		}
+	}
+}
+
+func (o *Map[K, V]) notify(e Event[K, V]) {
+	for _, ob := range o.observers.list {
+		ob.fn(e)
+	}
+}
+
+func (o *Map[K, V]) notifyReordered() {
+	if o.observers != nil {
+		o.notify(Event[K, V]{Kind: EventReordered})
+	}
+}
@@ -0,0 +1,101 @@
+package ordered
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_Observe(t *testing.T) {
+	om := NewMap[string, int]()
+	var events []Event[string, int]
+	unsubscribe := om.Observe(func(e Event[string, int]) {
+		events = append(events, e)
+	})
+
+	om.Set("a", 1)
+	om.Set("b", 2)
+	om.Set("a", 10)
+	om.Del("b")
+	om.Del("nonexistent")
+	om.InsertAt(0, "c", 3)
+	om.Reverse()
+	om.SortKeys(strings.Compare)
+	om.Clear()
+
+	require.Equal(t, []Event[string, int]{
+		{Kind: EventInserted, Key: "a", Value: 1, Index: 0},
+		{Kind: EventInserted, Key: "b", Value: 2, Index: 1},
+		{Kind: EventUpdated, Key: "a", Value: 10, OldValue: 1},
+		{Kind: EventDeleted, Key: "b", Value: 2},
+		{Kind: EventInserted, Key: "c", Value: 3, Index: 0},
+		{Kind: EventReordered},
+		{Kind: EventReordered},
+		{Kind: EventCleared},
+	}, events)
+
+	unsubscribe()
+	unsubscribe() // no-op
+	events = nil
+	om.Set("x", 1)
+	require.Empty(t, events)
+	require.Nil(t, om.observers)
+}
+
+func TestOrderedMap_Observe_Reorder(t *testing.T) {
+	om := newPositionTestMap("a", "b", "c")
+	var kinds []EventKind
+	om.Observe(func(e Event[string, int]) {
+		kinds = append(kinds, e.Kind)
+	})
+
+	om.MoveToFront("c")
+	om.MoveToBack("c")
+	om.MoveToBack("c") // already at the back
+	om.MoveBefore("c", "a")
+	om.MoveAfter("c", "a")
+	om.Swap("a", "b")
+	om.Swap("a", "a")
+	om.InsertAfter("a", "b", 20) // existing key: updated and moved
+	om.InsertBefore("a", "d", 4)
+	require.Equal(t, []EventKind{
+		EventReordered,
+		EventReordered,
+		EventReordered,
+		EventReordered,
+		EventReordered,
+		EventUpdated, EventReordered,
+		EventInserted,
+	}, kinds)
+	require.Equal(t, []string{"c", "d", "a", "b"}, om.Keys())
+}
+
+func TestOrderedMap_Observe_Multiple(t *testing.T) {
+	om := NewMap[string, int]()
+	var calls []string
+	var unsubscribeFirst func()
+	unsubscribeFirst = om.Observe(func(e Event[string, int]) {
+		calls = append(calls, "first:"+e.Key)
+		// unsubscribing while being notified does not affect the current notification
+		unsubscribeFirst()
+	})
+	om.Observe(func(e Event[string, int]) {
+		calls = append(calls, "second:"+e.Key)
+	})
+
+	om.Set("a", 1)
+	om.Set("b", 2)
+	require.Equal(t, []string{"first:a", "second:a", "second:b"}, calls)
+
+	// clones do not inherit observers
+	clone := om.Clone()
+	clone.Set("c", 3)
+	require.Len(t, calls, 3)
+}
+
+func TestEventKind_String(t *testing.T) {
+	require.Equal(t, "inserted", EventInserted.String())
+	require.Equal(t, "reordered", EventReordered.String())
+	require.Equal(t, "unknown", EventKind(0).String())
+}
@@ -0,0 +1,229 @@
+package ordered
+
+import (
+	"fmt"
+	"slices"
+)
+
+// At returns the key and value at index i in order.
+//
+// It is O(1) for the first entry, and for any entry unless entries have
+// been deleted since the map was last compacted, in which case it is O(n).
+// It panics if i is out of range.
+func (o *Map[K, V]) At(i int) (K, V) {
+	checkIndex(i, o.Len())
+	e := &o.entries[o.slot(i)]
+	return e.key, e.value
+}
+
+// IndexOf returns the index of key in order, or -1 if key is not present.
+//
+// Like At, it is O(1) unless there are holes left by deleted entries.
+func (o *Map[K, V]) IndexOf(key K) int {
+	slot, ok := o.index[key]
+	if !ok {
+		return -1
+	}
+	if o.holes == 0 {
+		return slot
+	}
+	pos := 0
+	for i := o.first; i < slot; i++ {
+		if !o.entries[i].deleted {
+			pos++
+		}
+	}
+	return pos
+}
+
+// InsertAt inserts key at index i, shifting the following entries back.
+//
+// If key already exists, its value is replaced and it is moved to index i.
+// i must be in [0, Len()] for a new key and in [0, Len()) for an existing one,
+// otherwise InsertAt panics.
+func (o *Map[K, V]) InsertAt(i int, key K, value V) {
+	o.own()
+	if o.Contains(key) {
+		checkIndex(i, o.Len())
+		o.Set(key, value)
+		o.compact()
+		o.move(o.index[key], i)
+		return
+	}
+
+	checkIndex(i, o.Len()+1)
+	o.compact()
+	if o.index == nil { // zero value Map
+		o.index = make(map[K]int)
+	}
+	o.insert(i, key, value)
+}
+
+// InsertBefore inserts key right before anchor.
+//
+// If key already exists, its value is replaced and it is moved before anchor.
+// If key is anchor, only the value is replaced.
+// It returns false without modifying the map if anchor is not present.
+func (o *Map[K, V]) InsertBefore(anchor, key K, value V) bool {
+	return o.insertNextTo(anchor, key, value, 0)
+}
+
+// InsertAfter inserts key right after anchor.
+//
+// If key already exists, its value is replaced and it is moved after anchor.
+// If key is anchor, only the value is replaced.
+// It returns false without modifying the map if anchor is not present.
+func (o *Map[K, V]) InsertAfter(anchor, key K, value V) bool {
+	return o.insertNextTo(anchor, key, value, 1)
+}
+
+func (o *Map[K, V]) insertNextTo(anchor, key K, value V, offset int) bool {
+	if !o.Contains(anchor) {
+		return false
+	}
+	o.own()
+	if o.Contains(key) {
+		o.Set(key, value)
+		o.moveNextTo(key, anchor, offset)
+		return true
+	}
+	o.compact()
+	o.insert(o.index[anchor]+offset, key, value)
+	return true
+}
+
+// insert inserts a new key at position i, entries must not contain holes.
+func (o *Map[K, V]) insert(i int, key K, value V) {
+	o.entries = slices.Insert(o.entries, i, entry[K, V]{key: key, value: value})
+	o.reindex(i, len(o.entries))
+	if o.observers != nil {
+		o.notify(Event[K, V]{Kind: EventInserted, Key: key, Value: value, Index: i})
+	}
+}
+
+// MoveToFront moves key to the front of the order.
+// It returns false if key is not present.
+func (o *Map[K, V]) MoveToFront(key K) bool {
+	if !o.Contains(key) {
+		return false
+	}
+	o.own()
+	o.compact()
+	o.move(o.index[key], 0)
+	return true
+}
+
+// MoveToBack moves key to the back of the order in amortized O(1).
+// It returns false if key is not present.
+func (o *Map[K, V]) MoveToBack(key K) bool {
+	i, ok := o.index[key]
+	if !ok {
+		return false
+	}
+	if i == len(o.entries)-1 {
+		return true
+	}
+	o.own()
+	i = o.index[key]
+	e := o.entries[i]
+	delete(o.index, key)
+	o.removeAt(i)
+	o.index[key] = len(o.entries)
+	o.entries = append(o.entries, e)
+	o.notifyReordered()
+	return true
+}
+
+// MoveBefore moves key right before anchor.
+// It returns false if either key or anchor is not present.
+func (o *Map[K, V]) MoveBefore(key, anchor K) bool {
+	if !o.Contains(key) || !o.Contains(anchor) {
+		return false
+	}
+	o.moveNextTo(key, anchor, 0)
+	return true
+}
+
+// MoveAfter moves key right after anchor.
+// It returns false if either key or anchor is not present.
+func (o *Map[K, V]) MoveAfter(key, anchor K) bool {
+	if !o.Contains(key) || !o.Contains(anchor) {
+		return false
+	}
+	o.moveNextTo(key, anchor, 1)
+	return true
+}
+
+// Swap swaps the positions of key1 and key2 in O(1).
+// It returns false if either key is not present.
+func (o *Map[K, V]) Swap(key1, key2 K) bool {
+	i, ok1 := o.index[key1]
+	j, ok2 := o.index[key2]
+	if !ok1 || !ok2 {
+		return false
+	}
+	if i == j {
+		return true
+	}
+	o.own()
+	i, j = o.index[key1], o.index[key2]
+	o.entries[i], o.entries[j] = o.entries[j], o.entries[i]
+	o.index[key1], o.index[key2] = j, i
+	o.notifyReordered()
+	return true
+}
+
+// moveNextTo moves key before (offset 0) or after (offset 1) anchor,
+// both must be present.
+func (o *Map[K, V]) moveNextTo(key, anchor K, offset int) {
+	if key == anchor {
+		return
+	}
+	o.own()
+	o.compact()
+	from, to := o.index[key], o.index[anchor]+offset
+	if from < to {
+		to-- // anchor moves one position up once key is taken out
+	}
+	o.move(from, to)
+}
+
+// move moves the entry at position from to position to, shifting the
+// entries in between, and notifies observers. entries must not contain holes.
+func (o *Map[K, V]) move(from, to int) {
+	if from == to {
+		return
+	}
+	e := o.entries[from]
+	if from < to {
+		copy(o.entries[from:to], o.entries[from+1:to+1])
+	} else {
+		copy(o.entries[to+1:from+1], o.entries[to:from])
+	}
+	o.entries[to] = e
+	o.reindex(min(from, to), max(from, to)+1)
+	o.notifyReordered()
+}
+
+// slot returns the position in entries of the i-th entry in order.
+func (o *Map[K, V]) slot(i int) int {
+	if o.holes == 0 {
+		return i
+	}
+	for slot := o.first; slot < len(o.entries); slot++ {
+		if o.entries[slot].deleted {
+			continue
+		}
+		if i == 0 {
+			return slot
+		}
+		i--
+	}
+	panic("unreachable")
+}
+
+func checkIndex(i, n int) {
+	if i < 0 || i >= n {
+		panic(fmt.Sprintf("ordered: index out of range [%d] with length %d", i, n))
+	}
+}
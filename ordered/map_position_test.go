@@ -0,0 +1,173 @@
+package ordered
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newPositionTestMap(keys ...string) *Map[string, int] {
+	om := NewMap[string, int]()
+	for i, k := range keys {
+		om.Set(k, i)
+	}
+	return om
+}
+
+func TestOrderedMap_At(t *testing.T) {
+	om := newPositionTestMap("a", "b", "c", "d")
+
+	k, v := om.At(2)
+	require.Equal(t, "c", k)
+	require.Equal(t, 2, v)
+
+	t.Run("with holes", func(t *testing.T) {
+		om.Del("b")
+		k, v := om.At(1)
+		require.Equal(t, "c", k)
+		require.Equal(t, 2, v)
+		k, _ = om.At(2)
+		require.Equal(t, "d", k)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		require.Panics(t, func() { om.At(-1) })
+		require.Panics(t, func() { om.At(3) })
+	})
+}
+
+func TestOrderedMap_IndexOf(t *testing.T) {
+	om := newPositionTestMap("a", "b", "c", "d")
+	require.Equal(t, 0, om.IndexOf("a"))
+	require.Equal(t, 3, om.IndexOf("d"))
+	require.Equal(t, -1, om.IndexOf("x"))
+
+	om.Del("b")
+	require.Equal(t, 1, om.IndexOf("c"))
+	require.Equal(t, 2, om.IndexOf("d"))
+	require.Equal(t, -1, om.IndexOf("b"))
+}
+
+func TestOrderedMap_InsertAt(t *testing.T) {
+	t.Run("new key", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c")
+		om.InsertAt(1, "x", 10)
+		require.Equal(t, []string{"a", "x", "b", "c"}, om.Keys())
+		om.InsertAt(0, "y", 20)
+		om.InsertAt(om.Len(), "z", 30)
+		require.Equal(t, []string{"y", "a", "x", "b", "c", "z"}, om.Keys())
+		require.Equal(t, []int{20, 0, 10, 1, 2, 30}, om.Values())
+		require.Equal(t, 3, om.IndexOf("b"))
+	})
+
+	t.Run("existing key is moved", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c", "d")
+		om.InsertAt(3, "a", 10)
+		require.Equal(t, []string{"b", "c", "d", "a"}, om.Keys())
+		require.Equal(t, 10, om.Get("a"))
+		om.InsertAt(0, "d", 30)
+		require.Equal(t, []string{"d", "b", "c", "a"}, om.Keys())
+		require.Equal(t, 4, om.Len())
+	})
+
+	t.Run("with holes", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c", "d")
+		om.Del("b")
+		om.InsertAt(1, "x", 10)
+		require.Equal(t, []string{"a", "x", "c", "d"}, om.Keys())
+	})
+
+	t.Run("zero value map", func(t *testing.T) {
+		var om Map[string, int]
+		om.InsertAt(0, "a", 1)
+		require.Equal(t, []string{"a"}, om.Keys())
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		om := newPositionTestMap("a", "b")
+		require.Panics(t, func() { om.InsertAt(3, "x", 0) })
+		require.Panics(t, func() { om.InsertAt(2, "a", 0) })
+		require.Panics(t, func() { om.InsertAt(-1, "x", 0) })
+	})
+}
+
+func TestOrderedMap_InsertBeforeAfter(t *testing.T) {
+	om := newPositionTestMap("a", "b", "c")
+
+	require.True(t, om.InsertBefore("b", "x", 10))
+	require.Equal(t, []string{"a", "x", "b", "c"}, om.Keys())
+
+	require.True(t, om.InsertAfter("c", "y", 20))
+	require.Equal(t, []string{"a", "x", "b", "c", "y"}, om.Keys())
+
+	// existing key is moved and updated
+	require.True(t, om.InsertAfter("a", "c", 30))
+	require.Equal(t, []string{"a", "c", "x", "b", "y"}, om.Keys())
+	require.Equal(t, 30, om.Get("c"))
+
+	require.True(t, om.InsertBefore("a", "y", 40))
+	require.Equal(t, []string{"y", "a", "c", "x", "b"}, om.Keys())
+	require.Equal(t, 40, om.Get("y"))
+
+	// key is anchor
+	require.True(t, om.InsertBefore("x", "x", 50))
+	require.Equal(t, []string{"y", "a", "c", "x", "b"}, om.Keys())
+	require.Equal(t, 50, om.Get("x"))
+
+	// missing anchor
+	require.False(t, om.InsertBefore("missing", "z", 0))
+	require.False(t, om.InsertAfter("missing", "z", 0))
+	require.False(t, om.Contains("z"))
+}
+
+func TestOrderedMap_Move(t *testing.T) {
+	t.Run("front and back", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c", "d")
+		require.True(t, om.MoveToFront("c"))
+		require.Equal(t, []string{"c", "a", "b", "d"}, om.Keys())
+		require.True(t, om.MoveToBack("a"))
+		require.Equal(t, []string{"c", "b", "d", "a"}, om.Keys())
+		require.True(t, om.MoveToBack("a"))
+		require.Equal(t, []string{"c", "b", "d", "a"}, om.Keys())
+		require.False(t, om.MoveToFront("x"))
+		require.False(t, om.MoveToBack("x"))
+		require.Equal(t, 1, om.Get("b"))
+	})
+
+	t.Run("move to back repeatedly", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c", "d")
+		for range 10 {
+			for _, k := range []string{"b", "a"} {
+				require.True(t, om.MoveToBack(k))
+			}
+		}
+		require.Equal(t, []string{"c", "d", "b", "a"}, om.Keys())
+		require.Equal(t, []int{2, 3, 1, 0}, om.Values())
+		require.LessOrEqual(t, len(om.entries), 2*om.Len())
+	})
+
+	t.Run("before and after", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c", "d")
+		require.True(t, om.MoveBefore("d", "b"))
+		require.Equal(t, []string{"a", "d", "b", "c"}, om.Keys())
+		require.True(t, om.MoveBefore("a", "c"))
+		require.Equal(t, []string{"d", "b", "a", "c"}, om.Keys())
+		require.True(t, om.MoveAfter("d", "a"))
+		require.Equal(t, []string{"b", "a", "d", "c"}, om.Keys())
+		require.True(t, om.MoveAfter("c", "b"))
+		require.Equal(t, []string{"b", "c", "a", "d"}, om.Keys())
+		require.True(t, om.MoveAfter("c", "c"))
+		require.Equal(t, []string{"b", "c", "a", "d"}, om.Keys())
+		require.False(t, om.MoveBefore("x", "a"))
+		require.False(t, om.MoveAfter("a", "x"))
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		om := newPositionTestMap("a", "b", "c")
+		require.True(t, om.Swap("a", "c"))
+		require.Equal(t, []string{"c", "b", "a"}, om.Keys())
+		require.Equal(t, []int{2, 1, 0}, om.Values())
+		require.Equal(t, 0, om.IndexOf("c"))
+		require.False(t, om.Swap("a", "x"))
+	})
+}
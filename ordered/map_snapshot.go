@@ -0,0 +1,119 @@
+package ordered
+
+import (
+	"iter"
+)
+
+// Snapshot returns a copy of the map in O(1).
+//
+// The map and the snapshot share their storage until either of them is
+// modified, which then copies it. Unlike Clone, Snapshot counts as a write
+// to o, it must not be called concurrently with other uses of o.
+func (o *Map[K, V]) Snapshot() *Map[K, V] {
+	o.shared = true
+	return o.share()
+}
+
+// share returns a map sharing the storage of o, which must already be marked as shared.
+func (o *Map[K, V]) share() *Map[K, V] {
+	return &Map[K, V]{
+		index:   o.index,
+		entries: o.entries,
+		holes:   o.holes,
+		first:   o.first,
+		shared:  true,
+
+		nestedMaps: o.nestedMaps,
+	}
+}
+
+// own copies the storage shared with snapshots, if any, before a write.
+func (o *Map[K, V]) own() {
+	if o.shared {
+		c := o.Clone()
+		o.index, o.entries, o.holes, o.first = c.index, c.entries, c.holes, c.first
+		o.shared = false
+	}
+}
+
+// ReadOnly returns a read-only snapshot of the map in O(1), see Snapshot.
+//
+// The snapshot can be handed to other goroutines and read concurrently
+// while the owner of o keeps modifying it.
+func (o *Map[K, V]) ReadOnly() *ReadOnlyMap[K, V] {
+	return &ReadOnlyMap[K, V]{m: o.Snapshot()}
+}
+
+// ReadOnlyMap is an immutable snapshot of a Map, safe for concurrent use.
+type ReadOnlyMap[K comparable, V any] struct {
+	m *Map[K, V]
+}
+
+func (r *ReadOnlyMap[K, V]) Get(key K) V {
+	return r.m.Get(key)
+}
+
+func (r *ReadOnlyMap[K, V]) TryGet(key K) (V, bool) {
+	return r.m.TryGet(key)
+}
+
+func (r *ReadOnlyMap[K, V]) Contains(key K) bool {
+	return r.m.Contains(key)
+}
+
+func (r *ReadOnlyMap[K, V]) Len() int {
+	return r.m.Len()
+}
+
+func (r *ReadOnlyMap[K, V]) At(i int) (K, V) {
+	return r.m.At(i)
+}
+
+func (r *ReadOnlyMap[K, V]) IndexOf(key K) int {
+	return r.m.IndexOf(key)
+}
+
+func (r *ReadOnlyMap[K, V]) Keys() []K {
+	return r.m.Keys()
+}
+
+func (r *ReadOnlyMap[K, V]) Values() []V {
+	return r.m.Values()
+}
+
+func (r *ReadOnlyMap[K, V]) Iter(yield func(key K, value V) bool) {
+	r.m.Iter(yield)
+}
+
+func (r *ReadOnlyMap[K, V]) All() iter.Seq2[K, V] {
+	return r.m.Iter
+}
+
+func (r *ReadOnlyMap[K, V]) KeysSeq() iter.Seq[K] {
+	return r.m.IterKeys
+}
+
+func (r *ReadOnlyMap[K, V]) ValuesSeq() iter.Seq[V] {
+	return r.m.IterValues
+}
+
+func (r *ReadOnlyMap[K, V]) Backward() iter.Seq2[K, V] {
+	return r.m.Backward()
+}
+
+// Clone returns a mutable copy of the snapshot.
+func (r *ReadOnlyMap[K, V]) Clone() *Map[K, V] {
+	return r.m.Clone()
+}
+
+func (r *ReadOnlyMap[K, V]) MarshalJSON() ([]byte, error) {
+	if r == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return r.m.MarshalJSON()
+}
+
+// Snapshot returns a copy of the set in O(1), see Map.Snapshot.
+func (s *Set[T]) Snapshot() *Set[T] {
+	return &Set[T]{m: *s.m.Snapshot()}
+}
@@ -0,0 +1,193 @@
+package ordered
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap_Snapshot(t *testing.T) {
+	newMap := func() *Map[string, int] {
+		om := NewMap[string, int]()
+		om.Set("a", 1)
+		om.Set("b", 2)
+		om.Set("c", 3)
+		return om
+	}
+
+	t.Run("shares storage until written", func(t *testing.T) {
+		om := newMap()
+		snap := om.Snapshot()
+		require.Same(t, &om.entries[0], &snap.entries[0])
+
+		om.Set("d", 4)
+		require.NotSame(t, &om.entries[0], &snap.entries[0])
+		require.Equal(t, []string{"a", "b", "c", "d"}, om.Keys())
+		require.Equal(t, []string{"a", "b", "c"}, snap.Keys())
+	})
+
+	t.Run("original mutations", func(t *testing.T) {
+		mutations := map[string]func(om *Map[string, int]){
+			"Set":         func(om *Map[string, int]) { om.Set("a", 10) },
+			"Del":         func(om *Map[string, int]) { om.Del("b") },
+			"Clear":       func(om *Map[string, int]) { om.Clear() },
+			"Reverse":     func(om *Map[string, int]) { om.Reverse() },
+			"InsertAt":    func(om *Map[string, int]) { om.InsertAt(0, "z", 0) },
+			"InsertAfter": func(om *Map[string, int]) { om.InsertAfter("a", "z", 0) },
+			"MoveToFront": func(om *Map[string, int]) { om.MoveToFront("c") },
+			"MoveToBack":  func(om *Map[string, int]) { om.MoveToBack("a") },
+			"MoveBefore":  func(om *Map[string, int]) { om.MoveBefore("c", "a") },
+			"Swap":        func(om *Map[string, int]) { om.Swap("a", "c") },
+			"SortKeys":    func(om *Map[string, int]) { om.SortKeys(func(a, b string) int { return strings.Compare(b, a) }) },
+		}
+		for name, mutate := range mutations {
+			t.Run(name, func(t *testing.T) {
+				om := newMap()
+				snap := om.Snapshot()
+				mutate(om)
+				require.Equal(t, []string{"a", "b", "c"}, snap.Keys())
+				require.Equal(t, []int{1, 2, 3}, snap.Values())
+				require.Equal(t, 2, snap.Get("b"))
+
+				want := newMap()
+				mutate(want)
+				require.Equal(t, want.Keys(), om.Keys())
+				require.Equal(t, want.Values(), om.Values())
+			})
+		}
+	})
+
+	t.Run("snapshot mutations", func(t *testing.T) {
+		om := newMap()
+		snap := om.Snapshot()
+		snap.Del("a")
+		snap.Set("b", 20)
+		require.Equal(t, []string{"b", "c"}, snap.Keys())
+		require.Equal(t, []int{20, 3}, snap.Values())
+		require.Equal(t, []string{"a", "b", "c"}, om.Keys())
+		require.Equal(t, []int{1, 2, 3}, om.Values())
+	})
+
+	t.Run("with holes", func(t *testing.T) {
+		om := newMap()
+		om.Set("d", 4)
+		om.Del("a")
+		snap := om.Snapshot()
+		om.Del("b")
+		om.Set("e", 5)
+		require.Equal(t, []string{"b", "c", "d"}, snap.Keys())
+		require.Equal(t, 0, snap.IndexOf("b"))
+		require.Equal(t, []string{"c", "d", "e"}, om.Keys())
+	})
+
+	t.Run("nested snapshots", func(t *testing.T) {
+		om := newMap()
+		s1 := om.Snapshot()
+		s2 := s1.Snapshot()
+		s1.Set("x", 0)
+		om.Del("a")
+		require.Equal(t, []string{"b", "c"}, om.Keys())
+		require.Equal(t, []string{"a", "b", "c", "x"}, s1.Keys())
+		require.Equal(t, []string{"a", "b", "c"}, s2.Keys())
+	})
+
+	t.Run("observers are not shared", func(t *testing.T) {
+		om := newMap()
+		var events int
+		om.Observe(func(Event[string, int]) { events++ })
+		snap := om.Snapshot()
+		snap.Set("x", 0)
+		require.Zero(t, events)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		s := NewSet[string]()
+		s.Add("a")
+		s.Add("b")
+		snap := s.Snapshot()
+		s.Remove("a")
+		require.Equal(t, []string{"a", "b"}, snap.Values())
+		require.Equal(t, []string{"b"}, s.Values())
+	})
+}
+
+func TestMap_ReadOnly(t *testing.T) {
+	om := NewMap[string, int]()
+	om.Set("a", 1)
+	om.Set("b", 2)
+	ro := om.ReadOnly()
+	om.Set("c", 3)
+	om.MoveToFront("b")
+
+	require.Equal(t, 2, ro.Len())
+	require.Equal(t, []string{"a", "b"}, ro.Keys())
+	require.Equal(t, []int{1, 2}, ro.Values())
+	require.Equal(t, 1, ro.IndexOf("b"))
+	k, v := ro.At(0)
+	require.Equal(t, "a", k)
+	require.Equal(t, 1, v)
+	require.False(t, ro.Contains("c"))
+
+	data, err := ro.MarshalJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":1,"b":2}`, string(data))
+
+	c := ro.Clone()
+	c.Set("z", 26)
+	require.Equal(t, []string{"a", "b"}, ro.Keys())
+}
+
+func TestMap_ReadOnlyConcurrent(t *testing.T) {
+	om := NewMap[int, int]()
+	for i := range 100 {
+		om.Set(i, i)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		ro := om.ReadOnly()
+		n := ro.Len()
+		wg.Go(func() {
+			for range 10 {
+				sum := 0
+				for k, v := range ro.All() {
+					require.Equal(t, k, v)
+					sum++
+				}
+				require.Equal(t, n, sum)
+			}
+		})
+		for i := range 50 {
+			om.Del(i + n - 100)
+			om.Set(n+i, n+i)
+		}
+	}
+	wg.Wait()
+}
+
+func BenchmarkMap_Snapshot(b *testing.B) {
+	om := NewMap[string, int]()
+	for i := range 10_000 {
+		om.Set(strconv.Itoa(i), i)
+	}
+
+	b.Run("Clone", func(b *testing.B) {
+		for b.Loop() {
+			_ = om.Clone()
+		}
+	})
+	b.Run("Snapshot", func(b *testing.B) {
+		for b.Loop() {
+			_ = om.Snapshot()
+		}
+	})
+	b.Run("SnapshotThenSet", func(b *testing.B) {
+		for b.Loop() {
+			_ = om.Snapshot()
+			om.Set("0", 0)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package ordered
+
+import (
+	"iter"
+	"slices"
+)
+
+// SortKeys sorts the map in place by key.
+func (o *Map[K, V]) SortKeys(cmp func(a, b K) int) {
+	o.sortEntries(func(a, b entry[K, V]) int {
+		return cmp(a.key, b.key)
+	}, false)
+}
+
+// SortFunc sorts the map in place with cmp, which is given both keys and values.
+// The sort is not guaranteed to be stable, use SortStableFunc if that matters.
+func (o *Map[K, V]) SortFunc(cmp func(k1 K, v1 V, k2 K, v2 V) int) {
+	o.sortEntries(func(a, b entry[K, V]) int {
+		return cmp(a.key, a.value, b.key, b.value)
+	}, false)
+}
+
+// SortStableFunc is like SortFunc, but keeps the original order of equal entries.
+func (o *Map[K, V]) SortStableFunc(cmp func(k1 K, v1 V, k2 K, v2 V) int) {
+	o.sortEntries(func(a, b entry[K, V]) int {
+		return cmp(a.key, a.value, b.key, b.value)
+	}, true)
+}
+
+func (o *Map[K, V]) sortEntries(cmp func(a, b entry[K, V]) int, stable bool) {
+	o.own()
+	o.compact()
+	if stable {
+		slices.SortStableFunc(o.entries, cmp)
+	} else {
+		slices.SortFunc(o.entries, cmp)
+	}
+	o.reindex(0, len(o.entries))
+	o.notifyReordered()
+}
+
+// SortedKeys returns the keys sorted with cmp, leaving the map untouched.
+func (o *Map[K, V]) SortedKeys(cmp func(a, b K) int) []K {
+	keys := o.Keys()
+	slices.SortFunc(keys, cmp)
+	return keys
+}
+
+// SortedIter returns an iterator over the entries sorted by key with cmp,
+// leaving the map untouched. The keys are sorted when iteration starts.
+func (o *Map[K, V]) SortedIter(cmp func(a, b K) int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, key := range o.SortedKeys(cmp) {
+			if !yield(key, o.Get(key)) {
+				return
+			}
+		}
+	}
+}
+
+// SortKeys sorts the set in place, like Map.SortKeys, the elements being the keys of a set.
+func (s *Set[T]) SortKeys(cmp func(a, b T) int) {
+	s.m.SortKeys(cmp)
+}
+
+// SortStableFunc is like SortKeys, but keeps the original order of equal elements.
+func (s *Set[T]) SortStableFunc(cmp func(a, b T) int) {
+	s.m.SortStableFunc(func(a T, _ struct{}, b T, _ struct{}) int {
+		return cmp(a, b)
+	})
+}
+
+// SortedKeys returns the elements sorted with cmp, leaving the set untouched.
+func (s *Set[T]) SortedKeys(cmp func(a, b T) int) []T {
+	return s.m.SortedKeys(cmp)
+}
+
+// SortedIter returns an iterator over the elements sorted with cmp,
+// leaving the set untouched. The elements are sorted when iteration starts.
+func (s *Set[T]) SortedIter(cmp func(a, b T) int) iter.Seq[T] {
+	return slices.Values(s.SortedKeys(cmp))
+}
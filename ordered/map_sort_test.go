@@ -0,0 +1,90 @@
+package ordered
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap_SortKeys(t *testing.T) {
+	om := newPositionTestMap("c", "a", "d", "b")
+	om.Del("d")
+	om.SortKeys(strings.Compare)
+	require.Equal(t, []string{"a", "b", "c"}, om.Keys())
+	require.Equal(t, []int{1, 3, 0}, om.Values())
+	require.Equal(t, 1, om.IndexOf("b"))
+
+	data, err := json.Marshal(om)
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1,"b":3,"c":0}`, string(data))
+}
+
+func TestOrderedMap_SortFunc(t *testing.T) {
+	om := NewMap[string, int]()
+	om.Set("x", 3)
+	om.Set("y", 1)
+	om.Set("z", 2)
+
+	// by value, descending
+	om.SortFunc(func(_ string, v1 int, _ string, v2 int) int {
+		return cmp.Compare(v2, v1)
+	})
+	require.Equal(t, []string{"x", "z", "y"}, om.Keys())
+}
+
+func TestOrderedMap_SortStableFunc(t *testing.T) {
+	om := NewMap[string, int]()
+	om.Set("a", 2)
+	om.Set("b", 1)
+	om.Set("c", 2)
+	om.Set("d", 1)
+
+	om.SortStableFunc(func(_ string, v1 int, _ string, v2 int) int {
+		return cmp.Compare(v1, v2)
+	})
+	require.Equal(t, []string{"b", "d", "a", "c"}, om.Keys())
+}
+
+func TestOrderedMap_SortedKeys(t *testing.T) {
+	om := newPositionTestMap("c", "a", "b")
+	require.Equal(t, []string{"a", "b", "c"}, om.SortedKeys(strings.Compare))
+	require.Equal(t, []string{"c", "a", "b"}, om.Keys(), "map should not be modified")
+
+	var keys []string
+	var values []int
+	for k, v := range om.SortedIter(strings.Compare) {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	require.Equal(t, []string{"a", "b", "c"}, keys)
+	require.Equal(t, []int{1, 2, 0}, values)
+	require.Equal(t, []string{"c", "a", "b"}, om.Keys(), "map should not be modified")
+}
+
+func TestSet_Sort(t *testing.T) {
+	s := NewSet[string]()
+	for _, v := range []string{"ccc", "a", "bb", "b", "aa"} {
+		s.Add(v)
+	}
+
+	require.Equal(t, []string{"a", "aa", "b", "bb", "ccc"}, s.SortedKeys(strings.Compare))
+	require.Equal(t, []string{"a", "aa", "b", "bb", "ccc"}, slices.Collect(s.SortedIter(strings.Compare)))
+	require.Equal(t, []string{"ccc", "a", "bb", "b", "aa"}, s.Values(), "set should not be modified")
+
+	s.SortStableFunc(func(a, b string) int {
+		return cmp.Compare(len(a), len(b))
+	})
+	require.Equal(t, []string{"a", "b", "bb", "aa", "ccc"}, s.Values())
+
+	s.SortKeys(strings.Compare)
+	require.Equal(t, []string{"a", "aa", "b", "bb", "ccc"}, s.Values())
+	require.True(t, s.Contains("bb"))
+
+	s.SortKeys(func(a, b string) int { return strings.Compare(b, a) })
+	require.Equal(t, []string{"ccc", "bb", "b", "aa", "a"}, s.Values())
+	require.True(t, s.Contains("aa"))
+}
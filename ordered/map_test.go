@@ -2,6 +2,8 @@ package ordered
 
 import (
 	"encoding/json"
+	"net/netip"
+	"slices"
 	"strings"
 	"testing"
 
@@ -13,15 +15,15 @@ func TestNewOrderedMap(t *testing.T) {
 		om := NewMap[string, int]()
 		require.NotNil(t, om)
 		require.Equal(t, 0, om.Len())
-		require.NotNil(t, om.m)
-		require.NotNil(t, om.keys)
+		require.NotNil(t, om.index)
+		require.NotNil(t, om.entries)
 	})
 
 	t.Run("with size hint", func(t *testing.T) {
 		om := NewMap[string, int](WithCapacity(10))
 		require.NotNil(t, om)
 		require.Equal(t, 0, om.Len())
-		require.Equal(t, 10, cap(om.keys))
+		require.Equal(t, 10, cap(om.entries))
 	})
 }
 
@@ -121,6 +123,50 @@ func TestOrderedMap_Del(t *testing.T) {
 	})
 }
 
+func TestOrderedMap_DelCompaction(t *testing.T) {
+	const size = 1000
+
+	om := NewMap[int, int]()
+	expected := make([]int, 0, size)
+	for i := range size {
+		om.Set(i, i*10)
+		expected = append(expected, i)
+	}
+
+	// delete every key not divisible by 3, in an order that leaves holes everywhere
+	for _, step := range []int{1, 2} {
+		for i := step; i < size; i += 3 {
+			om.Del(i)
+		}
+	}
+	expected = slices.DeleteFunc(expected, func(i int) bool { return i%3 != 0 })
+
+	require.Equal(t, len(expected), om.Len())
+	require.Equal(t, expected, om.Keys())
+	for _, k := range expected {
+		v, ok := om.TryGet(k)
+		require.True(t, ok)
+		require.Equal(t, k*10, v)
+	}
+	require.LessOrEqual(t, len(om.entries), 2*om.Len(), "holes should be compacted")
+
+	// deleted keys are appended again
+	om.Set(1, 10)
+	require.Equal(t, 1, om.Keys()[om.Len()-1])
+
+	for _, k := range slices.Backward(expected) {
+		om.Del(k)
+	}
+	require.Equal(t, []int{1}, om.Keys())
+	require.LessOrEqual(t, len(om.entries), 2)
+
+	// deleting the last entry drops the trailing holes as well
+	om.Del(1)
+	require.Equal(t, 0, om.Len())
+	require.Empty(t, om.entries)
+	require.Equal(t, 0, om.holes)
+}
+
 func TestOrderedMap_Clear(t *testing.T) {
 	om := NewMap[string, int](WithCapacity(5))
 	om.Set("a", 1)
@@ -135,7 +181,7 @@ func TestOrderedMap_Clear(t *testing.T) {
 	require.Empty(t, keys)
 
 	// Capacity should be preserved
-	require.Equal(t, 5, cap(om.keys))
+	require.Equal(t, 5, cap(om.entries))
 }
 
 func TestOrderedMap_Len(t *testing.T) {
@@ -177,7 +223,7 @@ func TestOrderedMap_Keys(t *testing.T) {
 		keys[0] = "modified"
 
 		originalKeys := om.Keys()
-		require.Equal(t, "modified", originalKeys[0], "modifying returned keys slice should not affect the map")
+		require.Equal(t, "z", originalKeys[0], "modifying returned keys slice should not affect the map")
 	})
 }
 
@@ -588,3 +634,193 @@ func TestOrderedMap_MarshalJSON_ComplexValues(t *testing.T) {
 		require.Equal(t, `{"pointer":"pointer value"}`, string(got))
 	})
 }
+
+func TestOrderedMap_UnmarshalJSON(t *testing.T) {
+	t.Run("preserves document order", func(t *testing.T) {
+		om := NewMap[string, int]()
+		err := json.Unmarshal([]byte(`{"z":26,"a":1,"m":13}`), om)
+		require.NoError(t, err)
+		require.Equal(t, []string{"z", "a", "m"}, om.Keys())
+		require.Equal(t, []int{26, 1, 13}, om.Values())
+	})
+
+	t.Run("typed values", func(t *testing.T) {
+		type Person struct {
+			Name string
+			Age  int
+		}
+
+		om := NewMap[string, Person]()
+		err := json.Unmarshal([]byte(`{"john":{"Name":"John","Age":30},"jane":{"Name":"Jane","Age":25}}`), om)
+		require.NoError(t, err)
+		require.Equal(t, []string{"john", "jane"}, om.Keys())
+		require.Equal(t, Person{Name: "Jane", Age: 25}, om.Get("jane"))
+	})
+
+	t.Run("keeps existing entries", func(t *testing.T) {
+		om := NewMap[string, int]()
+		om.Set("b", 2)
+		om.Set("a", 1)
+
+		err := json.Unmarshal([]byte(`{"c":3,"a":10}`), om)
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "a", "c"}, om.Keys())
+		require.Equal(t, []int{2, 10, 3}, om.Values())
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		om := NewMap[string, int]()
+		err := json.Unmarshal([]byte(`{"a":1,"b":2,"a":3}`), om)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, om.Keys())
+		require.Equal(t, 3, om.Get("a"))
+	})
+
+	t.Run("null", func(t *testing.T) {
+		om := NewMap[string, int]()
+		om.Set("a", 1)
+		err := json.Unmarshal([]byte(`null`), om)
+		require.NoError(t, err)
+		require.Equal(t, 1, om.Len())
+	})
+
+	t.Run("not an object", func(t *testing.T) {
+		om := NewMap[string, int]()
+		err := json.Unmarshal([]byte(`[1,2,3]`), om)
+		var typeErr *json.UnmarshalTypeError
+		require.ErrorAs(t, err, &typeErr)
+	})
+
+	t.Run("value type mismatch", func(t *testing.T) {
+		om := NewMap[string, int]()
+		err := json.Unmarshal([]byte(`{"a":"not a number"}`), om)
+		require.Error(t, err)
+	})
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		om := NewMap[any, int]()
+		err := om.UnmarshalJSON([]byte(`{"1":1}`))
+		require.ErrorIs(t, err, ErrUnsupportedKeyType)
+		require.ErrorIs(t, err, ErrKeyTypeNotString)
+	})
+
+	t.Run("as struct field", func(t *testing.T) {
+		var v struct {
+			Map *Map[string, string] `json:"map"`
+		}
+		err := json.Unmarshal([]byte(`{"map":{"y":"1","x":"2"}}`), &v)
+		require.NoError(t, err)
+		require.Equal(t, []string{"y", "x"}, v.Map.Keys())
+	})
+
+	t.Run("nested objects as plain maps by default", func(t *testing.T) {
+		om := NewMap[string, any]()
+		err := json.Unmarshal([]byte(`{"nested":{"b":1,"a":2}}`), om)
+		require.NoError(t, err)
+		require.IsType(t, map[string]any{}, om.Get("nested"))
+	})
+
+	t.Run("nested objects as ordered maps", func(t *testing.T) {
+		om := NewMap[string, any](WithNestedMaps())
+		data := `{"nested":{"b":1,"a":{"y":null,"x":true}},"array":[{"d":"4","c":"3"},[1,2]],"null":null}`
+		err := json.Unmarshal([]byte(data), om)
+		require.NoError(t, err)
+		require.Equal(t, []string{"nested", "array", "null"}, om.Keys())
+
+		nested, ok := om.Get("nested").(*Map[string, any])
+		require.True(t, ok)
+		require.Equal(t, []string{"b", "a"}, nested.Keys())
+
+		inner, ok := nested.Get("a").(*Map[string, any])
+		require.True(t, ok)
+		require.Equal(t, []string{"y", "x"}, inner.Keys())
+		require.Equal(t, []any{nil, true}, inner.Values())
+
+		array, ok := om.Get("array").([]any)
+		require.True(t, ok)
+		require.Len(t, array, 2)
+		elem, ok := array[0].(*Map[string, any])
+		require.True(t, ok)
+		require.Equal(t, []string{"d", "c"}, elem.Keys())
+		require.Equal(t, []any{1.0, 2.0}, array[1])
+
+		require.Nil(t, om.Get("null"))
+
+		// round trip
+		got, err := om.MarshalJSON()
+		require.NoError(t, err)
+		require.Equal(t, data, string(got))
+	})
+}
+
+// upperKey is a string kind implementing encoding.TextMarshaler and encoding.TextUnmarshaler.
+type upperKey string
+
+func (k upperKey) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(k))), nil
+}
+
+func (k *upperKey) UnmarshalText(text []byte) error {
+	*k = upperKey(strings.ToLower(string(text)))
+	return nil
+}
+
+func TestOrderedMap_JSON_NonStringKeys(t *testing.T) {
+	t.Run("int keys", func(t *testing.T) {
+		om := NewMap[int, string]()
+		om.Set(3, "three")
+		om.Set(-1, "minus one")
+		om.Set(2, "two")
+
+		got, err := json.Marshal(om)
+		require.NoError(t, err)
+		require.Equal(t, `{"3":"three","-1":"minus one","2":"two"}`, string(got))
+
+		decoded := NewMap[int, string]()
+		require.NoError(t, json.Unmarshal(got, decoded))
+		require.Equal(t, []int{3, -1, 2}, decoded.Keys())
+		require.Equal(t, om.Values(), decoded.Values())
+	})
+
+	t.Run("uint keys", func(t *testing.T) {
+		om := NewMap[uint8, bool]()
+		require.NoError(t, json.Unmarshal([]byte(`{"255":true,"0":false}`), om))
+		require.Equal(t, []uint8{255, 0}, om.Keys())
+
+		var typeErr *json.UnmarshalTypeError
+		require.ErrorAs(t, json.Unmarshal([]byte(`{"256":true}`), om), &typeErr)
+		require.ErrorAs(t, json.Unmarshal([]byte(`{"-1":true}`), om), &typeErr)
+		require.ErrorAs(t, json.Unmarshal([]byte(`{"abc":true}`), om), &typeErr)
+	})
+
+	t.Run("text marshaler keys", func(t *testing.T) {
+		om := NewMap[netip.Addr, int]()
+		om.Set(netip.MustParseAddr("10.0.0.2"), 2)
+		om.Set(netip.MustParseAddr("::1"), 1)
+
+		got, err := json.Marshal(om)
+		require.NoError(t, err)
+		require.Equal(t, `{"10.0.0.2":2,"::1":1}`, string(got))
+
+		decoded := NewMap[netip.Addr, int]()
+		require.NoError(t, json.Unmarshal(got, decoded))
+		require.Equal(t, om.Keys(), decoded.Keys())
+
+		require.Error(t, json.Unmarshal([]byte(`{"not an ip":1}`), decoded))
+	})
+
+	t.Run("string kind with text marshaler", func(t *testing.T) {
+		om := NewMap[upperKey, int]()
+		om.Set("a", 1)
+
+		// string kinds are encoded as is
+		got, err := json.Marshal(om)
+		require.NoError(t, err)
+		require.Equal(t, `{"a":1}`, string(got))
+
+		// but decoded with UnmarshalText
+		decoded := NewMap[upperKey, int]()
+		require.NoError(t, json.Unmarshal([]byte(`{"B":2}`), decoded))
+		require.Equal(t, []upperKey{"b"}, decoded.Keys())
+	})
+}
@@ -0,0 +1,119 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// UnmarshalJSON decodes a JSON object into the map, appending keys in the
+// order they appear in the document.
+//
+// Like encoding/json, existing entries are kept and duplicated keys overwrite
+// the previous value while keeping its position. A JSON null is a no-op.
+func (o *Map[K, V]) UnmarshalJSON(data []byte) error {
+	codec := keyDecoderOf[K]()
+	if codec == keyUnsupported {
+		return ErrUnsupportedKeyType
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil { // null
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return &json.UnmarshalTypeError{
+			Value:  describeToken(tok),
+			Type:   reflect.TypeFor[*Map[K, V]](),
+			Offset: dec.InputOffset(),
+		}
+	}
+
+	decodeAny := o.nestedMaps && reflect.TypeFor[V]() == reflect.TypeFor[any]()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, err := decodeKey[K](codec, tok.(string))
+		if err != nil {
+			return err
+		}
+
+		var value V
+		if decodeAny {
+			v, err := decodeNestedValue(dec)
+			if err != nil {
+				return err
+			}
+			value, _ = v.(V) // v is nil for JSON null
+		} else if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		o.Set(key, value)
+	}
+
+	// consume the closing '}'
+	_, err = dec.Token()
+	return err
+}
+
+// decodeNestedValue decodes the next JSON value from dec,
+// turning objects into *Map[string, any] and arrays into []any.
+func decodeNestedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		m := NewMap[string, any](WithNestedMaps())
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeNestedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			m.Set(tok.(string), v)
+		}
+		_, err = dec.Token()
+		return m, err
+	case json.Delim('['):
+		arr := make([]any, 0)
+		for dec.More() {
+			v, err := decodeNestedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	default:
+		return tok, nil
+	}
+}
+
+func describeToken(tok json.Token) string {
+	switch tok.(type) {
+	case json.Delim:
+		return "array"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	default:
+		return fmt.Sprintf("%T", tok)
+	}
+}
@@ -0,0 +1,442 @@
+package ordered
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidXMLName is returned when a key or the root name is not a valid XML name.
+var ErrInvalidXMLName = errors.New("invalid XML name")
+
+type xmlOptions struct {
+	attrPrefix string
+	textKey    string
+	root       string
+}
+
+var defaultXMLOptions = xmlOptions{root: "map"}
+
+// XMLOption configures the XML encoding of an XMLMap.
+type XMLOption func(*xmlOptions)
+
+// WithXMLAttrPrefix makes keys beginning with prefix map to attributes, e.g. "@" for "@id",
+// see XMLMap.MarshalXML.
+func WithXMLAttrPrefix(prefix string) XMLOption {
+	return func(o *xmlOptions) {
+		o.attrPrefix = prefix
+	}
+}
+
+// WithXMLTextKey makes key map to the text content of the element, e.g. "#text".
+func WithXMLTextKey(key string) XMLOption {
+	return func(o *xmlOptions) {
+		o.textKey = key
+	}
+}
+
+// WithXMLRoot sets the name of the element written when encoding/xml does not name it,
+// e.g. when the map is marshalled directly rather than as a struct field. It defaults to "map".
+func WithXMLRoot(name string) XMLOption {
+	return func(o *xmlOptions) {
+		o.root = name
+	}
+}
+
+// isAttr reports whether key is written as an attribute.
+func (x *xmlOptions) isAttr(key string) bool {
+	return x.attrPrefix != "" && strings.HasPrefix(key, x.attrPrefix) && len(key) > len(x.attrPrefix)
+}
+
+// XMLMap encodes and decodes a map as XML with options, see XML.
+type XMLMap[V any] struct {
+	m    *Map[string, V]
+	opts xmlOptions
+}
+
+// XML returns m wrapped for encoding/xml with opts, e.g.
+//
+//	xml.Marshal(ordered.XML(m, ordered.WithXMLAttrPrefix("@")))
+//
+// Without options it encodes and decodes like m itself.
+func XML[V any](m *Map[string, V], opts ...XMLOption) *XMLMap[V] {
+	x := &XMLMap[V]{m: m, opts: defaultXMLOptions}
+	for _, o := range opts {
+		o(&x.opts)
+	}
+	return x
+}
+
+// MarshalXML writes the map as an element with a child element per entry, in insertion order.
+//
+// Keys beginning with the prefix set by WithXMLAttrPrefix are written as attributes and the key
+// set by WithXMLTextKey as text content, their values must be strings, numbers, booleans, []byte
+// or implement encoding.TextMarshaler. Other values are encoded by encoding/xml, so slices are
+// written as repeated elements and nil values are omitted. Nested *Map[string, any] are written
+// with the same options.
+//
+// Element and attribute names must match the XML Name production without colons,
+// which encoding/xml would read back as namespace prefixes, or ErrInvalidXMLName is returned.
+func (x *XMLMap[V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if x == nil || x.m == nil {
+		return ErrNilOrderedMap
+	}
+	if start.Name.Local == reflect.TypeFor[XMLMap[V]]().Name() {
+		start.Name.Local = ""
+	}
+	return x.m.marshalXML(e, start, &x.opts)
+}
+
+// UnmarshalXML decodes the child elements of start into the map, see Map.UnmarshalXML.
+//
+// Attributes are read as keys with the prefix set by WithXMLAttrPrefix and text content
+// into the key set by WithXMLTextKey, trimmed of surrounding white space. They are dropped
+// if the option is not set. Nested *Map[string, any] are decoded with the same options.
+func (x *XMLMap[V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if x == nil || x.m == nil {
+		return ErrNilOrderedMap
+	}
+	return x.m.unmarshalXMLRoot(d, start, &x.opts)
+}
+
+// MarshalXML writes the map as an element named "map" with a child element per entry,
+// in insertion order, see XMLMap.MarshalXML. Keys must be strings, or ErrKeyTypeNotString
+// is returned. Use XML to write attributes, text content or another root name.
+func (o *Map[K, V]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if o == nil {
+		return ErrNilOrderedMap
+	}
+	return o.marshalXML(e, start, &defaultXMLOptions)
+}
+
+func (o *Map[K, V]) marshalXML(e *xml.Encoder, start xml.StartElement, opts *xmlOptions) error {
+	if reflect.TypeFor[K]().Kind() != reflect.String {
+		return ErrKeyTypeNotString
+	}
+	codec := keyEncoderOf[K]()
+	// encoding/xml names the element after the type when the caller does not
+	if start.Name.Local == "" || start.Name.Local == reflect.TypeFor[Map[K, V]]().Name() {
+		root := opts.root
+		if root == "" {
+			root = defaultXMLOptions.root
+		}
+		if !isXMLName(root) {
+			return fmt.Errorf("%w: root %q", ErrInvalidXMLName, root)
+		}
+		start.Name = xml.Name{Local: root}
+	}
+
+	keys := make([]string, 0, o.Len())
+	for key, value := range o.Iter {
+		name, err := encodeKey(codec, key)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, name)
+		if !opts.isAttr(name) {
+			if !(opts.textKey != "" && name == opts.textKey) && !isXMLName(name) {
+				return fmt.Errorf("%w: element %q", ErrInvalidXMLName, name)
+			}
+			continue
+		}
+		if !isXMLName(name[len(opts.attrPrefix):]) {
+			return fmt.Errorf("%w: attribute %q", ErrInvalidXMLName, name)
+		}
+		text, ok, err := xmlText(value)
+		if err != nil {
+			return fmt.Errorf("attribute %q: %w", name, err)
+		}
+		if ok {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name[len(opts.attrPrefix):]}, Value: text})
+		}
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	i := 0
+	for _, value := range o.Iter {
+		name := keys[i]
+		i++
+		switch {
+		case opts.isAttr(name):
+		case opts.textKey != "" && name == opts.textKey:
+			text, ok, err := xmlText(value)
+			if err != nil {
+				return fmt.Errorf("text %q: %w", name, err)
+			}
+			if ok {
+				if err := e.EncodeToken(xml.CharData(text)); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := encodeXMLElement(e, name, value, opts); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// encodeXMLElement writes v as an element named name, passing opts down to nested maps.
+func encodeXMLElement(e *xml.Encoder, name string, v any, opts *xmlOptions) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v := v.(type) {
+	case *Map[string, any]:
+		if v != nil {
+			return v.marshalXML(e, start, opts)
+		}
+	case []any:
+		for _, item := range v {
+			if err := encodeXMLElement(e, name, item, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return e.EncodeElement(v, start)
+}
+
+// xmlText formats v as an attribute value or text content, ok is false if v is nil.
+func xmlText(v any) (text string, ok bool, err error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "", false, nil
+		}
+		if m, isText := rv.Interface().(encoding.TextMarshaler); isText {
+			b, err := m.MarshalText()
+			return string(b), true, err
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "", false, nil
+	}
+	if m, isText := rv.Interface().(encoding.TextMarshaler); isText {
+		b, err := m.MarshalText()
+		return string(b), true, err
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), true, nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes()), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("cannot marshal %s as XML text", rv.Type())
+}
+
+// UnmarshalXML decodes the child elements of start into the map, in the order
+// they appear in the document, like Map.UnmarshalJSON for existing entries.
+// Keys must be strings, or ErrKeyTypeNotString is returned.
+//
+// Attributes and text content are dropped, use XML to read them. Child elements are decoded
+// by encoding/xml, except for Map[K, any], where elements holding only text are decoded as
+// strings, others as *Map[string, any], and repeated elements are collected into []any.
+func (o *Map[K, V]) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return o.unmarshalXMLRoot(d, start, &defaultXMLOptions)
+}
+
+// unmarshalXMLRoot decodes start into o, including its text content.
+func (o *Map[K, V]) unmarshalXMLRoot(d *xml.Decoder, start xml.StartElement, opts *xmlOptions) error {
+	text, err := o.unmarshalXML(d, start, opts)
+	if err != nil {
+		return err
+	}
+	if text != "" && opts.textKey != "" {
+		return o.setXMLText(opts.textKey, text)
+	}
+	return nil
+}
+
+// unmarshalXML decodes the attributes and child elements of start, and returns its trimmed text content.
+func (o *Map[K, V]) unmarshalXML(d *xml.Decoder, start xml.StartElement, opts *xmlOptions) (string, error) {
+	if reflect.TypeFor[K]().Kind() != reflect.String {
+		return "", ErrKeyTypeNotString
+	}
+	codec := keyDecoderOf[K]()
+	decodeAny := reflect.TypeFor[V]() == reflect.TypeFor[any]()
+
+	if opts.attrPrefix != "" {
+		for _, attr := range start.Attr {
+			if err := o.setXMLText(opts.attrPrefix+attr.Name.Local, attr.Value); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var text strings.Builder
+	seen := make(map[K]struct{})
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			key, err := decodeKey[K](codec, tok.Name.Local)
+			if err != nil {
+				return "", err
+			}
+			// repeated elements are decoded into the previous value, e.g. appended to slices
+			var value V
+			_, repeated := seen[key]
+			if repeated {
+				value = o.Get(key)
+			}
+			seen[key] = struct{}{}
+
+			if decodeAny {
+				child, err := decodeXMLAny(d, tok, opts)
+				if err != nil {
+					return "", err
+				}
+				if repeated {
+					if list, ok := any(value).([]any); ok {
+						child = append(list, child)
+					} else {
+						child = []any{value, child}
+					}
+				}
+				value, _ = child.(V)
+			} else if err := d.DecodeElement(&value, &tok); err != nil {
+				return "", err
+			}
+			o.Set(key, value)
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			return strings.TrimSpace(text.String()), nil
+		}
+	}
+}
+
+// setXMLText sets name to the attribute value or text content text.
+func (o *Map[K, V]) setXMLText(name, text string) error {
+	key, err := decodeKey[K](keyDecoderOf[K](), name)
+	if err != nil {
+		return err
+	}
+	var value V
+	if err := setXMLText(reflect.ValueOf(&value).Elem(), text); err != nil {
+		return fmt.Errorf("%q: %w", name, err)
+	}
+	o.Set(key, value)
+	return nil
+}
+
+// decodeXMLAny decodes the element start into a string if it holds only text, or a *Map[string, any].
+func decodeXMLAny(d *xml.Decoder, start xml.StartElement, opts *xmlOptions) (any, error) {
+	m := NewMap[string, any]()
+	text, err := m.unmarshalXML(d, start, opts)
+	if err != nil {
+		return nil, err
+	}
+	if m.Len() == 0 {
+		return text, nil
+	}
+	if text != "" && opts.textKey != "" {
+		m.Set(opts.textKey, text)
+	}
+	return m, nil
+}
+
+// isXMLName reports whether s matches the Name production of XML 1.0 without colons.
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !isXMLNameStartChar(r) && (i == 0 || !isXMLNameChar(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isXMLNameStartChar(r rune) bool {
+	return r == '_' ||
+		'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z' ||
+		0xC0 <= r && r <= 0xD6 || 0xD8 <= r && r <= 0xF6 || 0xF8 <= r && r <= 0x2FF ||
+		0x370 <= r && r <= 0x37D || 0x37F <= r && r <= 0x1FFF || 0x200C <= r && r <= 0x200D ||
+		0x2070 <= r && r <= 0x218F || 0x2C00 <= r && r <= 0x2FEF || 0x3001 <= r && r <= 0xD7FF ||
+		0xF900 <= r && r <= 0xFDCF || 0xFDF0 <= r && r <= 0xFFFD || 0x10000 <= r && r <= 0xEFFFF
+}
+
+func isXMLNameChar(r rune) bool {
+	return r == '-' || r == '.' || '0' <= r && r <= '9' || r == 0xB7 ||
+		0x300 <= r && r <= 0x36F || 0x203F <= r && r <= 0x2040
+}
+
+// setXMLText stores the attribute value or text content s in v.
+func setXMLText(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(s))
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setXMLText(v.Elem(), s)
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot unmarshal XML text into %s", v.Type())
+}
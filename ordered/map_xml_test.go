@@ -0,0 +1,219 @@
+package ordered
+
+import (
+	"encoding/xml"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ xml.Marshaler   = (*Map[string, int])(nil)
+	_ xml.Unmarshaler = (*Map[string, int])(nil)
+	_ xml.Marshaler   = (*XMLMap[int])(nil)
+	_ xml.Unmarshaler = (*XMLMap[int])(nil)
+)
+
+func TestMap_MarshalXML(t *testing.T) {
+	t.Run("insertion order", func(t *testing.T) {
+		om := NewMap[string, int]()
+		om.Set("z", 1)
+		om.Set("a", 2)
+		om.Set("m", 3)
+		om.Del("m")
+		data, err := xml.Marshal(om)
+		require.NoError(t, err)
+		require.Equal(t, `<map><z>1</z><a>2</a></map>`, string(data))
+	})
+
+	t.Run("attributes, text and root", func(t *testing.T) {
+		om := NewMap[string, any]()
+		om.Set("@id", 7)
+		om.Set("#text", "a & b")
+		om.Set("@addr", netip.MustParseAddr("::1"))
+		om.Set("@none", nil)
+		data, err := xml.Marshal(XML(om, WithXMLAttrPrefix("@"), WithXMLTextKey("#text"), WithXMLRoot("item")))
+		require.NoError(t, err)
+		require.Equal(t, `<item id="7" addr="::1">a &amp; b</item>`, string(data))
+	})
+
+	t.Run("nested values", func(t *testing.T) {
+		child := NewMap[string, any]()
+		child.Set("@lang", "en")
+		child.Set("name", "b")
+		om := NewMap[string, any]()
+		om.Set("title", "doc")
+		om.Set("child", child)
+		om.Set("tags", []any{"x", 1.5})
+		om.Set("ports", []int{80, 443})
+		om.Set("none", nil)
+		om.Set("point", struct {
+			X int `xml:"x,attr"`
+		}{X: 1})
+		data, err := xml.Marshal(XML(om, WithXMLAttrPrefix("@")))
+		require.NoError(t, err)
+		require.Equal(t, `<map><title>doc</title><child lang="en"><name>b</name></child>`+
+			`<tags>x</tags><tags>1.5</tags><ports>80</ports><ports>443</ports><point x="1"></point></map>`, string(data))
+	})
+
+	t.Run("as a field", func(t *testing.T) {
+		type doc struct {
+			XMLName xml.Name     `xml:"doc"`
+			Values  *XMLMap[int] `xml:"values"`
+		}
+		values := NewMap[string, int]()
+		values.Set("b", 2)
+		values.Set("a", 1)
+		data, err := xml.MarshalIndent(doc{Values: XML(values, WithXMLRoot("ignored"))}, "", "  ")
+		require.NoError(t, err)
+		require.Equal(t, `<doc>
+  <values>
+    <b>2</b>
+    <a>1</a>
+  </values>
+</doc>`, string(data))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		om := NewMap[string, any]()
+		om.Set("@bad", []int{1})
+		_, err := xml.Marshal(XML(om, WithXMLAttrPrefix("@")))
+		require.EqualError(t, err, `attribute "@bad": cannot marshal []int as XML text`)
+
+		_, err = xml.Marshal(XML[int](nil))
+		require.ErrorIs(t, err, ErrNilOrderedMap)
+	})
+
+	t.Run("non-string keys", func(t *testing.T) {
+		ints := NewMap[int, any]()
+		ints.Set(1, "a")
+		_, err := xml.Marshal(ints)
+		require.ErrorIs(t, err, ErrKeyTypeNotString)
+		_, err = xml.Marshal(NewMap[float64, int]())
+		require.ErrorIs(t, err, ErrKeyTypeNotString)
+
+		type name string
+		names := NewMap[name, int]()
+		names.Set("a", 1)
+		data, err := xml.Marshal(names)
+		require.NoError(t, err)
+		require.Equal(t, `<map><a>1</a></map>`, string(data))
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		for _, key := range []string{"", "a b", "<", "1a", "-a", "a:b", "a&b"} {
+			om := NewMap[string, int]()
+			om.Set(key, 1)
+			_, err := xml.Marshal(om)
+			require.ErrorIs(t, err, ErrInvalidXMLName, key)
+		}
+
+		nested := NewMap[string, any]()
+		nested.Set("child", NewMap[string, any]())
+		nested.Get("child").(*Map[string, any]).Set("a b", 1)
+		_, err := xml.Marshal(nested)
+		require.ErrorIs(t, err, ErrInvalidXMLName)
+
+		om := NewMap[string, any]()
+		om.Set("@a b", 1)
+		_, err = xml.Marshal(XML(om, WithXMLAttrPrefix("@")))
+		require.ErrorIs(t, err, ErrInvalidXMLName)
+
+		_, err = xml.Marshal(XML(NewMap[string, any](), WithXMLRoot("a b")))
+		require.ErrorIs(t, err, ErrInvalidXMLName)
+
+		valid := NewMap[string, any]()
+		valid.Set("_a-1.b", 1)
+		valid.Set("été", 2)
+		valid.Set("#text", "t")
+		data, err := xml.Marshal(XML(valid, WithXMLTextKey("#text")))
+		require.NoError(t, err)
+		require.Equal(t, `<map><_a-1.b>1</_a-1.b><été>2</été>t</map>`, string(data))
+	})
+}
+
+func TestMap_UnmarshalXML(t *testing.T) {
+	t.Run("document order", func(t *testing.T) {
+		om := NewMap[string, int]()
+		om.Set("a", 0)
+		require.NoError(t, xml.Unmarshal([]byte(`<map><z>1</z><a>2</a><m> 3 </m></map>`), om))
+		require.Equal(t, []string{"a", "z", "m"}, om.Keys())
+		require.Equal(t, []int{2, 1, 3}, om.Values())
+	})
+
+	t.Run("attributes and text", func(t *testing.T) {
+		om := NewMap[string, any]()
+		opts := []XMLOption{WithXMLAttrPrefix("@"), WithXMLTextKey("#text")}
+		data := `<item id="7">
+			text
+			<child lang="en"><name>b</name> more </child>
+			<tag>x</tag>
+			<empty/>
+			<tag>y</tag>
+			<tag>z</tag>
+		</item>`
+		require.NoError(t, xml.Unmarshal([]byte(data), XML(om, opts...)))
+		require.Equal(t, []string{"@id", "child", "tag", "empty", "#text"}, om.Keys())
+		require.Equal(t, "7", om.Get("@id"))
+		require.Equal(t, "text", om.Get("#text"))
+		require.Equal(t, []any{"x", "y", "z"}, om.Get("tag"))
+		require.Equal(t, "", om.Get("empty"))
+
+		child := om.Get("child").(*Map[string, any])
+		require.Equal(t, []string{"@lang", "name", "#text"}, child.Keys())
+		require.Equal(t, []any{"en", "b", "more"}, child.Values())
+
+		out, err := xml.Marshal(XML(child, opts...))
+		require.NoError(t, err)
+		require.Equal(t, `<map lang="en"><name>b</name>more</map>`, string(out))
+	})
+
+	t.Run("without options", func(t *testing.T) {
+		om := NewMap[string, any]()
+		require.NoError(t, xml.Unmarshal([]byte(`<item id="7">text<child>a</child></item>`), om))
+		require.Equal(t, []string{"child"}, om.Keys())
+		require.Equal(t, "a", om.Get("child"))
+	})
+
+	t.Run("typed values", func(t *testing.T) {
+		type point struct {
+			X int `xml:"x,attr"`
+		}
+		points := NewMap[string, point]()
+		require.NoError(t, xml.Unmarshal([]byte(`<map><b x="2"/><a x="1"/></map>`), points))
+		require.Equal(t, []string{"b", "a"}, points.Keys())
+		require.Equal(t, []point{{2}, {1}}, points.Values())
+
+		lists := NewMap[string, []int]()
+		lists.Set("a", []int{0})
+		require.NoError(t, xml.Unmarshal([]byte(`<map><a>1</a><b>2</b><a>3</a></map>`), lists))
+		require.Equal(t, []string{"a", "b"}, lists.Keys())
+		require.Equal(t, [][]int{{1, 3}, {2}}, lists.Values())
+
+		ints := NewMap[int, string]()
+		require.ErrorIs(t, xml.Unmarshal([]byte(`<map><x>1</x></map>`), ints), ErrKeyTypeNotString)
+		addrs := NewMap[string, netip.Addr]()
+		require.NoError(t, xml.Unmarshal([]byte(`<map ip="::1"/>`), XML(addrs, WithXMLAttrPrefix("@"))))
+		require.Equal(t, netip.MustParseAddr("::1"), addrs.Get("@ip"))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		opts := []XMLOption{WithXMLAttrPrefix("-"), WithXMLTextKey("_"), WithXMLRoot("config")}
+		data := `<config version="2"><server name="a"><port>80</port>primary</server><server name="b"><port>81</port></server><debug>true</debug></config>`
+		om := NewMap[string, any]()
+		require.NoError(t, xml.Unmarshal([]byte(data), XML(om, opts...)))
+		out, err := xml.Marshal(XML(om, opts...))
+		require.NoError(t, err)
+		require.Equal(t, data, string(out))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		om := NewMap[string, int]()
+		require.ErrorContains(t, xml.Unmarshal([]byte(`<map id="x"/>`), XML(om, WithXMLAttrPrefix("@"))), `"@id": strconv.ParseInt`)
+		require.Error(t, xml.Unmarshal([]byte(`<map><a>x</a></map>`), om))
+		require.Error(t, xml.Unmarshal([]byte(`<map><a>1</a>`), om))
+		require.ErrorIs(t, xml.Unmarshal([]byte(`<map/>`), NewMap[float64, int]()), ErrKeyTypeNotString)
+		require.ErrorIs(t, xml.Unmarshal([]byte(`<map/>`), XML[int](nil)), ErrNilOrderedMap)
+	})
+}
@@ -0,0 +1,76 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// MergePatch applies a JSON merge patch (RFC 7396) to doc in place.
+//
+// Members of the patch set to null are removed, objects are merged recursively
+// and other values replace the existing ones. Existing keys keep their position
+// and new keys are appended in the order of the patch.
+// The patch must be a JSON object, doc is left unchanged if it is not valid.
+func MergePatch(doc *Map[string, any], patch []byte) error {
+	p, err := decodeNested(patch)
+	if err != nil {
+		return err
+	}
+	pm, ok := p.(*Map[string, any])
+	if !ok {
+		return &json.UnmarshalTypeError{
+			Value: describeValue(p),
+			Type:  reflect.TypeFor[*Map[string, any]](),
+		}
+	}
+	mergePatchInto(doc, pm)
+	return nil
+}
+
+func mergePatchInto(doc, patch *Map[string, any]) {
+	for k, v := range patch.Iter {
+		if v == nil {
+			doc.Del(k)
+			continue
+		}
+		doc.Set(k, mergePatchValue(doc.Get(k), v))
+	}
+}
+
+// mergePatchValue returns the result of merging patch into target,
+// target is modified in place when both are objects.
+func mergePatchValue(target, patch any) any {
+	pm, ok := patch.(*Map[string, any])
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(*Map[string, any])
+	if !ok {
+		tm = NewMap[string, any](WithNestedMaps())
+	}
+	mergePatchInto(tm, pm)
+	return tm
+}
+
+// decodeNested decodes a JSON document like UnmarshalJSON with WithNestedMaps.
+func decodeNested(data []byte) (any, error) {
+	// validate first for the syntax errors of encoding/json, including trailing data
+	if err := json.Unmarshal(data, new(json.RawMessage)); err != nil {
+		return nil, err
+	}
+	return decodeNestedValue(json.NewDecoder(bytes.NewReader(data)))
+}
+
+func describeValue(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case *Map[string, any]:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return describeToken(v)
+	}
+}
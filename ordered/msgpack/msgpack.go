@@ -0,0 +1,343 @@
+// Package msgpack encodes and decodes MessagePack, keeping the order of
+// ordered maps and sets on the wire.
+//
+// Ordered containers (Map, Set, Frozen, BiMap, MultiMap, LRU, SyncMap, ...)
+// are written in iteration order and read back in the order of the document.
+// Other values follow the rules of encoding/json where they apply: structs are
+// maps of their exported fields named by the `msgpack` tag, Go maps are written
+// with sorted keys and types implementing encoding.TextMarshaler as strings.
+// time.Time uses the timestamp extension type.
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	_ "github.com/yusing/ds/ordered" // provides the maps decoded into interfaces
+	"github.com/yusing/ds/ordered/internal/codec"
+)
+
+// UnmarshalTypeError describes a value that cannot be decoded into a Go type.
+type UnmarshalTypeError = codec.UnmarshalTypeError
+
+const timestampExt = -1
+
+// Marshal returns the MessagePack encoding of v.
+func Marshal(v any) ([]byte, error) {
+	return Append(nil, v)
+}
+
+// Append appends the MessagePack encoding of v to dst and returns the extended buffer.
+func Append(dst []byte, v any) ([]byte, error) {
+	w := writer{buf: dst}
+	if err := codec.Encode(&w, v, "msgpack"); err != nil {
+		return dst, err
+	}
+	return w.buf, nil
+}
+
+// Unmarshal decodes the MessagePack value in data into v, which must be a non-nil pointer.
+//
+// Decoding into an interface stores nil, bool, int64 (uint64 if it overflows),
+// float64, string, []byte, time.Time, []any, and maps as *ordered.Map[string, any],
+// or *ordered.Map[any, any] if any key is not a string.
+func Unmarshal(data []byte, v any) error {
+	r := reader{data: data}
+	if err := codec.Decode(&r, v, "msgpack"); err != nil {
+		return err
+	}
+	if r.off != len(data) {
+		return fmt.Errorf("msgpack: %d bytes of trailing data", len(data)-r.off)
+	}
+	return nil
+}
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) Nil() {
+	w.buf = append(w.buf, 0xc0)
+}
+
+func (w *writer) Bool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *writer) Int(i int64) {
+	switch {
+	case i >= 0:
+		w.Uint(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(i)) // negative fixint
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xd2), uint32(i))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xd3), uint64(i))
+	}
+}
+
+func (w *writer) Uint(u uint64) {
+	switch {
+	case u < 0x80:
+		w.buf = append(w.buf, byte(u)) // positive fixint
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xce), uint32(u))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcf), u)
+	}
+}
+
+func (w *writer) Float32(f float32) {
+	w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xca), math.Float32bits(f))
+}
+
+func (w *writer) Float64(f float64) {
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcb), math.Float64bits(f))
+}
+
+func (w *writer) String(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xda), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xdb), uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *writer) Bytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xc5), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xc6), uint32(n))
+	}
+	w.buf = append(w.buf, b...)
+}
+
+// Time writes t with the timestamp extension type, in its smallest form.
+func (w *writer) Time(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case nsec == 0 && sec >= 0 && sec <= math.MaxUint32: // timestamp 32
+		w.buf = append(w.buf, 0xd6, byte(timestampExt&0xff))
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(sec))
+	case sec >= 0 && sec < 1<<34: // timestamp 64
+		w.buf = append(w.buf, 0xd7, byte(timestampExt&0xff))
+		w.buf = binary.BigEndian.AppendUint64(w.buf, nsec<<34|uint64(sec))
+	default: // timestamp 96
+		w.buf = append(w.buf, 0xc7, 12, byte(timestampExt&0xff))
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(nsec))
+		w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(sec))
+	}
+}
+
+func (w *writer) ArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xdc), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xdd), uint32(n))
+	}
+}
+
+func (w *writer) MapHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xde), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xdf), uint32(n))
+	}
+}
+
+type reader struct {
+	data []byte
+	off  int
+}
+
+// read returns the next n bytes.
+func (r *reader) read(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.off {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of n bytes.
+func (r *reader) uint(n int) (uint64, error) {
+	b, err := r.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+// length reads a length of n bytes, at most the bytes left divided by size.
+func (r *reader) length(n, size int) (int, error) {
+	u, err := r.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(r.data)-r.off)/uint64(size) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return int(u), nil
+}
+
+func (r *reader) Break() (bool, error) {
+	return false, nil // MessagePack has no indefinite lengths
+}
+
+func (r *reader) Next() (codec.Token, error) {
+	start := r.off
+	b, err := r.read(1)
+	if err != nil {
+		return codec.Token{}, err
+	}
+	c := b[0]
+	switch {
+	case c < 0x80:
+		return codec.Token{Kind: codec.KindUint, Uint: uint64(c)}, nil
+	case c >= 0xe0:
+		return codec.Token{Kind: codec.KindInt, Int: int64(int8(c))}, nil
+	case c&0xf0 == 0x80:
+		return r.container(codec.KindMap, int(c&0x0f), 2)
+	case c&0xf0 == 0x90:
+		return r.container(codec.KindArray, int(c&0x0f), 1)
+	case c&0xe0 == 0xa0:
+		return r.bytes(codec.KindString, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return codec.Token{Kind: codec.KindNil}, nil
+	case 0xc2, 0xc3:
+		return codec.Token{Kind: codec.KindBool, Bool: c == 0xc3}, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.length(1<<(c-0xc4), 1)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return r.bytes(codec.KindBytes, n)
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.length(1<<(c-0xd9), 1)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return r.bytes(codec.KindString, n)
+	case 0xca:
+		u, err := r.uint(4)
+		return codec.Token{Kind: codec.KindFloat, Float: float64(math.Float32frombits(uint32(u)))}, err
+	case 0xcb:
+		u, err := r.uint(8)
+		return codec.Token{Kind: codec.KindFloat, Float: math.Float64frombits(u)}, err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (c - 0xcc))
+		return codec.Token{Kind: codec.KindUint, Uint: u}, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		n := 1 << (c - 0xd0)
+		u, err := r.uint(n)
+		i := int64(u<<(64-8*n)) >> (64 - 8*n) // sign extend
+		if i >= 0 {
+			return codec.Token{Kind: codec.KindUint, Uint: uint64(i)}, err
+		}
+		return codec.Token{Kind: codec.KindInt, Int: i}, err
+	case 0xdc, 0xdd:
+		n, err := r.length(2<<(c-0xdc), 1)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindArray, Len: n}, nil
+	case 0xde, 0xdf:
+		n, err := r.length(2<<(c-0xde), 2)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return codec.Token{Kind: codec.KindMap, Len: n}, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.ext(1 << (c - 0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.length(1<<(c-0xc7), 1)
+		if err != nil {
+			return codec.Token{}, err
+		}
+		return r.ext(n)
+	}
+	return codec.Token{}, fmt.Errorf("msgpack: invalid byte 0x%02x at offset %d", c, start)
+}
+
+func (r *reader) container(kind codec.Kind, n, size int) (codec.Token, error) {
+	if n*size > len(r.data)-r.off {
+		return codec.Token{}, io.ErrUnexpectedEOF
+	}
+	return codec.Token{Kind: kind, Len: n}, nil
+}
+
+func (r *reader) bytes(kind codec.Kind, n int) (codec.Token, error) {
+	b, err := r.read(n)
+	return codec.Token{Kind: kind, Bytes: b}, err
+}
+
+// ext reads an extension of n bytes of data, only timestamps are supported.
+func (r *reader) ext(n int) (codec.Token, error) {
+	start := r.off
+	b, err := r.read(n + 1)
+	if err != nil {
+		return codec.Token{}, err
+	}
+	if typ := int8(b[0]); typ != timestampExt {
+		return codec.Token{}, fmt.Errorf("msgpack: unsupported extension type %d at offset %d", typ, start)
+	}
+	data := b[1:]
+	var sec, nsec int64
+	switch n {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		sec, nsec = int64(u&(1<<34-1)), int64(u>>34)
+	case 12:
+		nsec = int64(binary.BigEndian.Uint32(data))
+		sec = int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return codec.Token{}, fmt.Errorf("msgpack: invalid timestamp length %d at offset %d", n, start)
+	}
+	if nsec > 999999999 {
+		return codec.Token{}, fmt.Errorf("msgpack: invalid timestamp nanoseconds %d at offset %d", nsec, start)
+	}
+	return codec.Token{Kind: codec.KindTime, Time: time.Unix(sec, nsec).UTC()}, nil
+}
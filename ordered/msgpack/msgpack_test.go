@@ -0,0 +1,302 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"io"
+	"math"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yusing/ds/ordered"
+)
+
+func requireEncoding(t *testing.T, want string, v any) {
+	t.Helper()
+	got, err := Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, want, hex.EncodeToString(got), "%#v", v)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("primitives", func(t *testing.T) {
+		requireEncoding(t, "c0", nil)
+		requireEncoding(t, "c3", true)
+		requireEncoding(t, "c2", false)
+		requireEncoding(t, "00", 0)
+		requireEncoding(t, "7f", 127)
+		requireEncoding(t, "cc80", 128)
+		requireEncoding(t, "cd0100", 256)
+		requireEncoding(t, "ce00010000", 65536)
+		requireEncoding(t, "cf0000000100000000", 1<<32)
+		requireEncoding(t, "ff", -1)
+		requireEncoding(t, "e0", -32)
+		requireEncoding(t, "d0df", -33)
+		requireEncoding(t, "d1ff7f", -129)
+		requireEncoding(t, "d2ffff7fff", -32769)
+		requireEncoding(t, "d38000000000000000", int64(math.MinInt64))
+		requireEncoding(t, "cfffffffffffffffff", uint64(math.MaxUint64))
+		requireEncoding(t, "ca3fc00000", float32(1.5))
+		requireEncoding(t, "cb3ff8000000000000", 1.5)
+		requireEncoding(t, "a3616263", "abc")
+		requireEncoding(t, "d920"+strings.Repeat("61", 32), strings.Repeat("a", 32))
+		requireEncoding(t, "c403010203", []byte{1, 2, 3})
+		requireEncoding(t, "93010203", []int{1, 2, 3})
+		requireEncoding(t, "92a161c0", []any{"a", nil})
+		requireEncoding(t, "c0", []int(nil))
+		requireEncoding(t, "82a16101a16202", map[string]int{"b": 2, "a": 1})
+		requireEncoding(t, "a93132372e302e302e31", netip.MustParseAddr("127.0.0.1"))
+	})
+
+	t.Run("time", func(t *testing.T) {
+		requireEncoding(t, "d6ff00000001", time.Unix(1, 0))
+		requireEncoding(t, "d7ff0000000400000001", time.Unix(1, 1))
+		requireEncoding(t, "c70cff00000000ffffffffffffffff", time.Unix(-1, 0))
+	})
+
+	t.Run("ordered map", func(t *testing.T) {
+		om := ordered.NewMap[string, any]()
+		om.Set("z", 1)
+		om.Set("a", []any{true})
+		om.Set("m", 2)
+		om.Del("m")
+		requireEncoding(t, "82a17a01a16191c3", om)
+		requireEncoding(t, "c0", (*ordered.Map[string, int])(nil))
+
+		ints := ordered.NewMap[int, string]()
+		ints.Set(2, "b")
+		ints.Set(-1, "a")
+		requireEncoding(t, "8202a162ffa161", ints)
+	})
+
+	t.Run("ordered containers", func(t *testing.T) {
+		s := ordered.NewSet[string]()
+		s.Add("y")
+		s.Add("x")
+		requireEncoding(t, "92a179a178", s)
+
+		m := ordered.NewMultiMap[string, int]()
+		m.Add("a", 1)
+		m.Add("b", 2)
+		m.Add("a", 3)
+		requireEncoding(t, "83a16101a16202a16103", m)
+
+		b := ordered.NewBiMap[string, int]()
+		require.NoError(t, b.Set("b", 1))
+		require.NoError(t, b.Set("a", 2))
+		requireEncoding(t, "82a16201a16102", b)
+
+		om := ordered.NewMap[string, int]()
+		om.Set("b", 1)
+		om.Set("a", 2)
+		requireEncoding(t, "82a16201a16102", om.Freeze())
+		requireEncoding(t, "82a16201a16102", om.ReadOnly())
+	})
+
+	t.Run("structs", func(t *testing.T) {
+		type inner struct {
+			Tags *ordered.Set[string] `msgpack:"tags"`
+		}
+		type config struct {
+			Name    string                    `msgpack:"name"`
+			Skip    int                       `msgpack:"-"`
+			Empty   string                    `msgpack:",omitempty"`
+			Values  ordered.Map[string, bool] `msgpack:"values"`
+			Inner   inner
+			private int
+		}
+		c := config{Name: "n", Skip: 1, Values: *ordered.NewMap[string, bool](), private: 1}
+		c.Values.Set("y", true)
+		c.Values.Set("x", false)
+		requireEncoding(t, "83a46e616d65a16ea676616c75657382a179c3a178c2a5496e6e657281a474616773c0", c)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Marshal(make(chan int))
+		require.EqualError(t, err, "msgpack: unsupported type chan int")
+
+		cyclic := []any{nil}
+		cyclic[0] = cyclic
+		_, err = Marshal(cyclic)
+		require.ErrorContains(t, err, "exceeded max depth")
+
+		dst := []byte{0xc0}
+		got, err := Append(dst, make(chan int))
+		require.Error(t, err)
+		require.Equal(t, dst, got)
+	})
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("into ordered map", func(t *testing.T) {
+		// {"z": 1, "a": [true], "n": {"y": nil, "x": "s"}}
+		data := mustDecodeHex(t, "83a17a01a16191c3a16e82a179c0a178a173")
+		om := ordered.NewMap[string, any]()
+		require.NoError(t, Unmarshal(data, om))
+		require.Equal(t, []string{"z", "a", "n"}, om.Keys())
+		require.Equal(t, int64(1), om.Get("z"))
+		require.Equal(t, []any{true}, om.Get("a"))
+		nested := om.Get("n").(*ordered.Map[string, any])
+		require.Equal(t, []string{"y", "x"}, nested.Keys())
+		require.Nil(t, nested.Get("y"))
+		require.Equal(t, "s", nested.Get("x"))
+
+		var p *ordered.Map[string, int]
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "82a16201a16102"), &p))
+		require.Equal(t, []string{"b", "a"}, p.Keys())
+		require.Equal(t, []int{1, 2}, p.Values())
+	})
+
+	t.Run("into any", func(t *testing.T) {
+		var v any
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "8202a162ffa161"), &v))
+		m := v.(*ordered.Map[any, any])
+		require.Equal(t, []any{int64(2), int64(-1)}, m.Keys())
+
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "cfffffffffffffffff"), &v))
+		require.Equal(t, uint64(math.MaxUint64), v)
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "ca3fc00000"), &v))
+		require.Equal(t, 1.5, v)
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "c403010203"), &v))
+		require.Equal(t, []byte{1, 2, 3}, v)
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "c0"), &v))
+		require.Nil(t, v)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		type item struct {
+			ID   int                  `msgpack:"id"`
+			Tags *ordered.Set[string] `msgpack:"tags"`
+		}
+		type doc struct {
+			Name    string                            `msgpack:"name"`
+			At      time.Time                         `msgpack:"at"`
+			Items   *ordered.Map[string, item]        `msgpack:"items"`
+			Headers *ordered.MultiMap[string, string] `msgpack:"headers"`
+			Aliases *ordered.BiMap[string, string]    `msgpack:"aliases"`
+			Frozen  *ordered.Frozen[int, float64]     `msgpack:"frozen"`
+			Addr    netip.Addr                        `msgpack:"addr"`
+			Ratio   float32                           `msgpack:"ratio"`
+			Counts  map[string]uint16                 `msgpack:"counts"`
+			Pair    [2]int8                           `msgpack:"pair"`
+			Raw     []byte                            `msgpack:"raw"`
+		}
+
+		tags := ordered.NewSet[string]()
+		tags.Add("b")
+		tags.Add("a")
+		items := ordered.NewMap[string, item]()
+		items.Set("second", item{ID: 2, Tags: tags})
+		items.Set("first", item{ID: 1})
+		headers := ordered.NewMultiMap[string, string]()
+		headers.Add("Set-Cookie", "a")
+		headers.Add("Host", "h")
+		headers.Add("Set-Cookie", "b")
+		aliases := ordered.NewBiMap[string, string]()
+		require.NoError(t, aliases.Set("home", "/"))
+		require.NoError(t, aliases.Set("docs", "/docs"))
+		in := doc{
+			Name:    "doc",
+			At:      time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC),
+			Items:   items,
+			Headers: headers,
+			Aliases: aliases,
+			Frozen:  ordered.NewFrozen[int, float64]().With(3, 0.5).With(1, -2),
+			Addr:    netip.MustParseAddr("::1"),
+			Ratio:   0.25,
+			Counts:  map[string]uint16{"x": 65535},
+			Pair:    [2]int8{-128, 127},
+			Raw:     []byte("raw"),
+		}
+		data, err := Marshal(in)
+		require.NoError(t, err)
+
+		var out doc
+		require.NoError(t, Unmarshal(data, &out))
+		require.Equal(t, in.Name, out.Name)
+		require.True(t, in.At.Equal(out.At))
+		require.Equal(t, []string{"second", "first"}, out.Items.Keys())
+		require.Equal(t, []string{"b", "a"}, out.Items.Get("second").Tags.Values())
+		require.Nil(t, out.Items.Get("first").Tags)
+		require.Equal(t, []string{"Set-Cookie", "Host"}, out.Headers.Keys())
+		require.Equal(t, []string{"a", "b"}, out.Headers.GetAll("Set-Cookie"))
+		require.Equal(t, []string{"home", "docs"}, out.Aliases.Keys())
+		k, _ := out.Aliases.GetByValue("/docs")
+		require.Equal(t, "docs", k)
+		require.Equal(t, []int{3, 1}, out.Frozen.Keys())
+		require.Equal(t, in.Addr, out.Addr)
+		require.Equal(t, in.Ratio, out.Ratio)
+		require.Equal(t, in.Counts, out.Counts)
+		require.Equal(t, in.Pair, out.Pair)
+		require.Equal(t, in.Raw, out.Raw)
+	})
+
+	t.Run("existing values", func(t *testing.T) {
+		om := ordered.NewMap[string, int]()
+		om.Set("a", 1)
+		om.Set("b", 2)
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "82a16303a16104"), om))
+		require.Equal(t, []string{"a", "b", "c"}, om.Keys())
+		require.Equal(t, []int{4, 2, 3}, om.Values())
+
+		p := om
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "c0"), &p))
+		require.Nil(t, p)
+
+		n := 5
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "c0"), &n))
+		require.Equal(t, 5, n)
+	})
+
+	t.Run("unknown struct fields and case", func(t *testing.T) {
+		var v struct {
+			Name string
+		}
+		// {"extra": {"x": [1, 2]}, "NAME": "n"}
+		require.NoError(t, Unmarshal(mustDecodeHex(t, "82a5657874726181a178920102a44e414d45a16e"), &v))
+		require.Equal(t, "n", v.Name)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var n int8
+		var typeErr *UnmarshalTypeError
+		require.ErrorAs(t, Unmarshal(mustDecodeHex(t, "cc80"), &n), &typeErr)
+		require.EqualError(t, typeErr, "msgpack: cannot unmarshal integer 128 into Go value of type int8")
+
+		var u uint
+		require.ErrorAs(t, Unmarshal(mustDecodeHex(t, "ff"), &u), &typeErr)
+		var s string
+		require.ErrorAs(t, Unmarshal(mustDecodeHex(t, "01"), &s), &typeErr)
+		require.EqualError(t, typeErr, "msgpack: cannot unmarshal integer into Go value of type string")
+
+		om := ordered.NewMap[string, int]()
+		require.ErrorAs(t, Unmarshal(mustDecodeHex(t, "9101"), om), &typeErr)
+
+		b := ordered.NewBiMap[string, int]()
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "82a16101a16201"), b), ordered.ErrValueCollision)
+
+		require.ErrorContains(t, Unmarshal(mustDecodeHex(t, "01"), om.ReadOnly()), "cannot unmarshal")
+
+		var v any
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "92"), &v), io.ErrUnexpectedEOF)
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "dd7fffffff"), &v), io.ErrUnexpectedEOF)
+		require.ErrorIs(t, Unmarshal(mustDecodeHex(t, "a3ab"), &v), io.ErrUnexpectedEOF)
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "c1"), &v), "msgpack: invalid byte 0xc1 at offset 0")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "d40100"), &v), "msgpack: unsupported extension type 1 at offset 1")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "0101"), &v), "msgpack: 1 bytes of trailing data")
+		require.ErrorContains(t, Unmarshal(mustDecodeHex(t, "81910101"), &v), "map key of type []interface {}")
+		require.EqualError(t, Unmarshal(mustDecodeHex(t, "01"), nil), "msgpack: Unmarshal(non-pointer or nil <nil>)")
+
+		nested := strings.Repeat("91", 20000) + "c0"
+		require.ErrorContains(t, Unmarshal(mustDecodeHex(t, nested), &v), "exceeded max depth")
+	})
+}
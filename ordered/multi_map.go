@@ -0,0 +1,243 @@
+package ordered
+
+import (
+	"iter"
+	"slices"
+)
+
+// MultiMap is a map that allows a key more than once and keeps the
+// insertion order of every (key, value) pair, like HTTP headers or query strings.
+//
+// Pairs are stored in a slice and each key indexes the positions of its pairs,
+// deleted pairs are left as holes and compacted once they make up half of
+// the slice, so Add, First, Last and DelAt are amortized O(1) and
+// GetAll and DelAll are O(number of values of the key).
+//
+// MultiMap is not safe for concurrent use.
+type MultiMap[K comparable, V any] struct {
+	index   map[K][]int   // key to the positions of its pairs in entries, in order
+	entries []entry[K, V] // ordered pairs, may contain holes
+	holes   int           // number of deleted pairs in entries
+
+	nestedMaps bool
+}
+
+func NewMultiMap[K comparable, V any](opts ...Option) *MultiMap[K, V] {
+	var opt option
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &MultiMap[K, V]{
+		index:   make(map[K][]int, opt.capacity),
+		entries: make([]entry[K, V], 0, opt.capacity),
+
+		nestedMaps: opt.nestedMaps,
+	}
+}
+
+// Add appends a (key, value) pair, keeping the existing values of key.
+func (m *MultiMap[K, V]) Add(key K, value V) {
+	if m.index == nil { // zero value MultiMap
+		m.index = make(map[K][]int)
+	}
+	m.index[key] = append(m.index[key], len(m.entries))
+	m.entries = append(m.entries, entry[K, V]{key: key, value: value})
+}
+
+// GetAll returns a copy of the values of key in order, or nil if key is not present.
+func (m *MultiMap[K, V]) GetAll(key K) []V {
+	positions := m.index[key]
+	if len(positions) == 0 {
+		return nil
+	}
+	values := make([]V, len(positions))
+	for i, p := range positions {
+		values[i] = m.entries[p].value
+	}
+	return values
+}
+
+// First returns the first value of key.
+func (m *MultiMap[K, V]) First(key K) (V, bool) {
+	positions := m.index[key]
+	if len(positions) == 0 {
+		var zero V
+		return zero, false
+	}
+	return m.entries[positions[0]].value, true
+}
+
+// Last returns the last value of key.
+func (m *MultiMap[K, V]) Last(key K) (V, bool) {
+	positions := m.index[key]
+	if len(positions) == 0 {
+		var zero V
+		return zero, false
+	}
+	return m.entries[positions[len(positions)-1]].value, true
+}
+
+func (m *MultiMap[K, V]) Contains(key K) bool {
+	_, ok := m.index[key]
+	return ok
+}
+
+// Count returns the number of values of key.
+func (m *MultiMap[K, V]) Count(key K) int {
+	return len(m.index[key])
+}
+
+// Len returns the number of pairs.
+func (m *MultiMap[K, V]) Len() int {
+	return len(m.entries) - m.holes
+}
+
+// KeyLen returns the number of distinct keys.
+func (m *MultiMap[K, V]) KeyLen() int {
+	return len(m.index)
+}
+
+// DelAll removes every value of key and returns how many were removed.
+func (m *MultiMap[K, V]) DelAll(key K) int {
+	positions, ok := m.index[key]
+	if !ok {
+		return 0
+	}
+	delete(m.index, key)
+	for _, p := range positions {
+		m.entries[p] = entry[K, V]{deleted: true}
+	}
+	m.holes += len(positions)
+	m.shrink()
+	return len(positions)
+}
+
+// DelAt removes the i-th value of key and returns it.
+// It returns false if key has no i-th value.
+func (m *MultiMap[K, V]) DelAt(key K, i int) (V, bool) {
+	positions := m.index[key]
+	if i < 0 || i >= len(positions) {
+		var zero V
+		return zero, false
+	}
+	p := positions[i]
+	value := m.entries[p].value
+	if len(positions) == 1 {
+		delete(m.index, key)
+	} else {
+		m.index[key] = slices.Delete(positions, i, i+1)
+	}
+	m.entries[p] = entry[K, V]{deleted: true}
+	m.holes++
+	m.shrink()
+	return value, true
+}
+
+// shrink drops the trailing holes and compacts the entries
+// once holes make up half of them.
+func (m *MultiMap[K, V]) shrink() {
+	n := len(m.entries)
+	for n > 0 && m.entries[n-1].deleted {
+		n--
+	}
+	m.holes -= len(m.entries) - n
+	clear(m.entries[n:])
+	m.entries = m.entries[:n]
+	if m.holes*2 > len(m.entries) {
+		m.compact()
+	}
+}
+
+// compact removes the holes left by deleted pairs and rebuilds the positions.
+func (m *MultiMap[K, V]) compact() {
+	if m.holes == 0 {
+		return
+	}
+	for key, positions := range m.index {
+		m.index[key] = positions[:0]
+	}
+	n := 0
+	for i := range m.entries {
+		if m.entries[i].deleted {
+			continue
+		}
+		m.entries[n] = m.entries[i]
+		key := m.entries[n].key
+		m.index[key] = append(m.index[key], n)
+		n++
+	}
+	clear(m.entries[n:])
+	m.entries = m.entries[:n]
+	m.holes = 0
+}
+
+// Keys returns the distinct keys in the order of their first value.
+func (m *MultiMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(m.index))
+	for p := range m.entries {
+		e := &m.entries[p]
+		if !e.deleted && m.index[e.key][0] == p {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// Iter yields every pair in order.
+func (m *MultiMap[K, V]) Iter(yield func(key K, value V) bool) {
+	// re-evaluate len(m.entries) on each iteration since yield may modify the map
+	for i := 0; i < len(m.entries); i++ {
+		e := &m.entries[i]
+		if e.deleted {
+			continue
+		}
+		if !yield(e.key, e.value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over every pair in order.
+func (m *MultiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Iter
+}
+
+// Values returns an iterator over the values of key in order.
+// The multimap must not be modified during the iteration.
+func (m *MultiMap[K, V]) Values(key K) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, p := range m.index[key] {
+			if !yield(m.entries[p].value) {
+				return
+			}
+		}
+	}
+}
+
+// Grouped returns an iterator over the distinct keys in the order of
+// their first value, with a copy of all their values.
+func (m *MultiMap[K, V]) Grouped() iter.Seq2[K, []V] {
+	return func(yield func(K, []V) bool) {
+		for _, key := range m.Keys() {
+			if !yield(key, m.GetAll(key)) {
+				return
+			}
+		}
+	}
+}
+
+func (m *MultiMap[K, V]) Clear() {
+	clear(m.index)
+	clear(m.entries)
+	m.entries = m.entries[:0]
+	m.holes = 0
+}
+
+func (m *MultiMap[K, V]) Clone() *MultiMap[K, V] {
+	clone := NewMultiMap[K, V](WithCapacity(m.Len()))
+	clone.nestedMaps = m.nestedMaps
+	for key, value := range m.Iter {
+		clone.Add(key, value)
+	}
+	return clone
+}
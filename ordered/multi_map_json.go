@@ -0,0 +1,215 @@
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// MarshalJSON encodes the multimap as an object of arrays, the keys in the order
+// of their first value, like {"a":[1,3],"b":[2]}.
+//
+// The order of the pairs across keys is lost, see MarshalJSONPairs to keep it.
+func (m *MultiMap[K, V]) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return appendJSON(m)
+}
+
+// MarshalJSONPairs encodes the multimap as an array of [key, value] pairs
+// in order, like [["a",1],["b",2],["a",3]].
+//
+// Keys are encoded as strings, like the keys of MarshalJSON.
+func (m *MultiMap[K, V]) MarshalJSONPairs() ([]byte, error) {
+	if m == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return appendJSON(multiMapPairs[K, V]{m})
+}
+
+func (m *MultiMap[K, V]) encodeJSON(e *jsonEncoder, depth int) error {
+	if m == nil {
+		e.buf = append(e.buf, "null"...)
+		return nil
+	}
+	codec := keyEncoderOf[K]()
+	if codec == keyUnsupported {
+		return ErrUnsupportedKeyType
+	}
+
+	e.buf = append(e.buf, '{')
+	i := 0
+	for p := range m.entries {
+		en := &m.entries[p]
+		if en.deleted {
+			continue
+		}
+		positions := m.index[en.key]
+		if positions[0] != p { // not the first value of the key
+			continue
+		}
+		if err := beginMember(e, i, codec, en.key, depth); err != nil {
+			return err
+		}
+		e.buf = append(e.buf, '[')
+		for j, q := range positions {
+			e.beginElement(j, depth+1)
+			if err := encodeValue(e, &m.entries[q].value, depth+2); err != nil {
+				return err
+			}
+			if err := e.maybeFlush(); err != nil {
+				return err
+			}
+		}
+		e.end(len(positions), depth+1, ']')
+		i++
+	}
+	e.end(i, depth, '}')
+	return nil
+}
+
+// multiMapPairs encodes a MultiMap as an array of pairs.
+type multiMapPairs[K comparable, V any] struct {
+	m *MultiMap[K, V]
+}
+
+func (mp multiMapPairs[K, V]) encodeJSON(e *jsonEncoder, depth int) error {
+	codec := keyEncoderOf[K]()
+	if codec == keyUnsupported {
+		return ErrUnsupportedKeyType
+	}
+
+	e.buf = append(e.buf, '[')
+	i := 0
+	for p := range mp.m.entries {
+		en := &mp.m.entries[p]
+		if en.deleted {
+			continue
+		}
+		e.beginElement(i, depth)
+		e.buf = append(e.buf, '[')
+		e.beginElement(0, depth+1)
+		var err error
+		if e.buf, err = appendKey(e.buf, codec, en.key, e.escapeHTML); err != nil {
+			return err
+		}
+		e.beginElement(1, depth+1)
+		if err := encodeValue(e, &en.value, depth+2); err != nil {
+			return err
+		}
+		e.end(2, depth+1, ']')
+		if err := e.maybeFlush(); err != nil {
+			return err
+		}
+		i++
+	}
+	e.end(i, depth, ']')
+	return nil
+}
+
+// UnmarshalJSON decodes either form written by MarshalJSON and MarshalJSONPairs,
+// appending the pairs to the existing ones in the order they appear in the document.
+// A JSON null is a no-op.
+func (m *MultiMap[K, V]) UnmarshalJSON(data []byte) error {
+	codec := keyDecoderOf[K]()
+	if codec == keyUnsupported {
+		return ErrUnsupportedKeyType
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil: // null
+		return nil
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, err := decodeKey[K](codec, tok.(string))
+			if err != nil {
+				return err
+			}
+			if err := m.expectToken(dec, json.Delim('[')); err != nil {
+				return err
+			}
+			for dec.More() {
+				value, err := m.decodeValue(dec)
+				if err != nil {
+					return err
+				}
+				m.Add(key, value)
+			}
+			if _, err := dec.Token(); err != nil { // ']'
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			if err := m.expectToken(dec, json.Delim('[')); err != nil {
+				return err
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			s, ok := tok.(string)
+			if !ok {
+				return m.typeError(dec, tok)
+			}
+			key, err := decodeKey[K](codec, s)
+			if err != nil {
+				return err
+			}
+			value, err := m.decodeValue(dec)
+			if err != nil {
+				return err
+			}
+			if err := m.expectToken(dec, json.Delim(']')); err != nil {
+				return err
+			}
+			m.Add(key, value)
+		}
+	default:
+		return m.typeError(dec, tok)
+	}
+
+	// consume the closing '}' or ']'
+	_, err = dec.Token()
+	return err
+}
+
+func (m *MultiMap[K, V]) decodeValue(dec *json.Decoder) (value V, err error) {
+	if m.nestedMaps && reflect.TypeFor[V]() == reflect.TypeFor[any]() {
+		v, err := decodeNestedValue(dec)
+		value, _ = v.(V) // v is nil for JSON null
+		return value, err
+	}
+	err = dec.Decode(&value)
+	return value, err
+}
+
+// expectToken reads the next token and fails if it is not want.
+func (m *MultiMap[K, V]) expectToken(dec *json.Decoder, want json.Token) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return m.typeError(dec, tok)
+	}
+	return nil
+}
+
+func (m *MultiMap[K, V]) typeError(dec *json.Decoder, tok json.Token) error {
+	return &json.UnmarshalTypeError{
+		Value:  describeToken(tok),
+		Type:   reflect.TypeFor[*MultiMap[K, V]](),
+		Offset: dec.InputOffset(),
+	}
+}
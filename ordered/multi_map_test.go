@@ -0,0 +1,304 @@
+package ordered
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pairsOf returns the pairs of m in order as "key=value" strings.
+func pairsOf[V any](m *MultiMap[string, V]) []string {
+	var pairs []string
+	for k, v := range m.Iter {
+		pairs = append(pairs, k+"="+jsonString(v))
+	}
+	return pairs
+}
+
+func jsonString(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func newHeaderTestMap() *MultiMap[string, string] {
+	m := NewMultiMap[string, string]()
+	m.Add("Accept", "text/html")
+	m.Add("Set-Cookie", "a=1")
+	m.Add("Accept", "application/json")
+	m.Add("Set-Cookie", "b=2")
+	m.Add("Host", "example.com")
+	m.Add("Set-Cookie", "c=3")
+	return m
+}
+
+func TestMultiMap(t *testing.T) {
+	t.Run("add and get", func(t *testing.T) {
+		m := newHeaderTestMap()
+		require.Equal(t, 6, m.Len())
+		require.Equal(t, 3, m.KeyLen())
+		require.Equal(t, []string{"a=1", "b=2", "c=3"}, m.GetAll("Set-Cookie"))
+		require.Nil(t, m.GetAll("Missing"))
+		require.Equal(t, 2, m.Count("Accept"))
+		require.Zero(t, m.Count("Missing"))
+		require.True(t, m.Contains("Host"))
+		require.False(t, m.Contains("Missing"))
+
+		v, ok := m.First("Accept")
+		require.True(t, ok)
+		require.Equal(t, "text/html", v)
+		v, ok = m.Last("Accept")
+		require.True(t, ok)
+		require.Equal(t, "application/json", v)
+		_, ok = m.First("Missing")
+		require.False(t, ok)
+		_, ok = m.Last("Missing")
+		require.False(t, ok)
+
+		require.Equal(t, []string{"Accept", "Set-Cookie", "Host"}, m.Keys())
+		require.Equal(t, []string{
+			`Accept="text/html"`, `Set-Cookie="a=1"`, `Accept="application/json"`,
+			`Set-Cookie="b=2"`, `Host="example.com"`, `Set-Cookie="c=3"`,
+		}, pairsOf(m))
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var m MultiMap[string, int]
+		require.Zero(t, m.Len())
+		m.Add("a", 1)
+		m.Add("a", 2)
+		require.Equal(t, []int{1, 2}, m.GetAll("a"))
+	})
+
+	t.Run("iterators", func(t *testing.T) {
+		m := newHeaderTestMap()
+		require.Equal(t, []string{"a=1", "b=2", "c=3"}, slices.Collect(m.Values("Set-Cookie")))
+		require.Empty(t, slices.Collect(m.Values("Missing")))
+
+		for v := range m.Values("Set-Cookie") {
+			require.Equal(t, "a=1", v)
+			break
+		}
+
+		var keys []string
+		var groups [][]string
+		for k, values := range m.Grouped() {
+			keys = append(keys, k)
+			groups = append(groups, values)
+		}
+		require.Equal(t, []string{"Accept", "Set-Cookie", "Host"}, keys)
+		require.Equal(t, [][]string{{"text/html", "application/json"}, {"a=1", "b=2", "c=3"}, {"example.com"}}, groups)
+
+		n := 0
+		for range m.All() {
+			n++
+			if n == 2 {
+				break
+			}
+		}
+		require.Equal(t, 2, n)
+	})
+
+	t.Run("DelAll", func(t *testing.T) {
+		m := newHeaderTestMap()
+		require.Equal(t, 3, m.DelAll("Set-Cookie"))
+		require.Zero(t, m.DelAll("Set-Cookie"))
+		require.Equal(t, 3, m.Len())
+		require.Equal(t, 2, m.KeyLen())
+		require.False(t, m.Contains("Set-Cookie"))
+		require.Equal(t, []string{"Accept", "Host"}, m.Keys())
+		require.Equal(t, []string{`Accept="text/html"`, `Accept="application/json"`, `Host="example.com"`}, pairsOf(m))
+
+		m.Add("Set-Cookie", "d=4")
+		require.Equal(t, []string{"Accept", "Host", "Set-Cookie"}, m.Keys())
+	})
+
+	t.Run("DelAt", func(t *testing.T) {
+		m := newHeaderTestMap()
+		v, ok := m.DelAt("Set-Cookie", 1)
+		require.True(t, ok)
+		require.Equal(t, "b=2", v)
+		require.Equal(t, []string{"a=1", "c=3"}, m.GetAll("Set-Cookie"))
+
+		_, ok = m.DelAt("Set-Cookie", 2)
+		require.False(t, ok)
+		_, ok = m.DelAt("Set-Cookie", -1)
+		require.False(t, ok)
+		_, ok = m.DelAt("Missing", 0)
+		require.False(t, ok)
+
+		v, ok = m.DelAt("Host", 0)
+		require.True(t, ok)
+		require.Equal(t, "example.com", v)
+		require.False(t, m.Contains("Host"))
+
+		v, ok = m.DelAt("Accept", 0)
+		require.True(t, ok)
+		require.Equal(t, "text/html", v)
+		require.Equal(t, []string{"Set-Cookie", "Accept"}, m.Keys())
+		require.Equal(t, []string{`Set-Cookie="a=1"`, `Accept="application/json"`, `Set-Cookie="c=3"`}, pairsOf(m))
+		require.Equal(t, 3, m.Len())
+	})
+
+	t.Run("compaction", func(t *testing.T) {
+		m := NewMultiMap[string, int]()
+		for i := range 100 {
+			m.Add(strconv.Itoa(i%10), i)
+		}
+		for i := range 9 {
+			m.DelAll(strconv.Itoa(i))
+		}
+		require.Equal(t, 10, m.Len())
+		require.LessOrEqual(t, m.holes*2, len(m.entries))
+		require.Equal(t, []int{9, 19, 29, 39, 49, 59, 69, 79, 89, 99}, m.GetAll("9"))
+
+		for range 10 {
+			_, ok := m.DelAt("9", 0)
+			require.True(t, ok)
+		}
+		require.Zero(t, m.Len())
+		require.Empty(t, m.entries)
+		require.Zero(t, m.holes)
+	})
+
+	t.Run("clear and clone", func(t *testing.T) {
+		m := newHeaderTestMap()
+		m.DelAt("Accept", 0)
+		clone := m.Clone()
+		require.Equal(t, pairsOf(m), pairsOf(clone))
+
+		clone.Add("Accept", "text/plain")
+		require.Equal(t, 1, m.Count("Accept"))
+
+		m.Clear()
+		require.Zero(t, m.Len())
+		require.Zero(t, m.KeyLen())
+		require.Equal(t, 6, clone.Len())
+	})
+}
+
+func TestMultiMap_JSON(t *testing.T) {
+	t.Run("marshal", func(t *testing.T) {
+		m := newHeaderTestMap()
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `{"Accept":["text/html","application/json"],"Set-Cookie":["a=1","b=2","c=3"],"Host":["example.com"]}`, string(data))
+
+		data, err = m.MarshalJSONPairs()
+		require.NoError(t, err)
+		require.Equal(t, `[["Accept","text/html"],["Set-Cookie","a=1"],["Accept","application/json"],["Set-Cookie","b=2"],["Host","example.com"],["Set-Cookie","c=3"]]`, string(data))
+
+		data, err = json.Marshal(NewMultiMap[string, int]())
+		require.NoError(t, err)
+		require.Equal(t, `{}`, string(data))
+
+		data, err = NewMultiMap[string, int]().MarshalJSONPairs()
+		require.NoError(t, err)
+		require.Equal(t, `[]`, string(data))
+
+		var nilMap *MultiMap[string, int]
+		_, err = nilMap.MarshalJSON()
+		require.ErrorIs(t, err, ErrNilOrderedMap)
+		_, err = nilMap.MarshalJSONPairs()
+		require.ErrorIs(t, err, ErrNilOrderedMap)
+	})
+
+	t.Run("marshal int keys", func(t *testing.T) {
+		m := NewMultiMap[int, bool]()
+		m.Add(2, true)
+		m.Add(1, false)
+		m.Add(2, false)
+		data, err := json.Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `{"2":[true,false],"1":[false]}`, string(data))
+
+		data, err = m.MarshalJSONPairs()
+		require.NoError(t, err)
+		require.Equal(t, `[["2",true],["1",false],["2",false]]`, string(data))
+	})
+
+	t.Run("indent", func(t *testing.T) {
+		m := NewMultiMap[string, int]()
+		m.Add("a", 1)
+		m.Add("a", 2)
+		om := NewMap[string, any]()
+		om.Set("m", m)
+
+		got, err := om.AppendJSON(nil, WithIndent("", "  "))
+		require.NoError(t, err)
+		want, err := json.MarshalIndent(map[string]any{"m": map[string][]int{"a": {1, 2}}}, "", "  ")
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got))
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		for _, data := range []string{
+			`{"Accept":["text/html","application/json"],"Set-Cookie":["a=1","b=2","c=3"],"Host":["example.com"]}`,
+			`[["Accept","text/html"],["Set-Cookie","a=1"],["Accept","application/json"],["Set-Cookie","b=2"],["Host","example.com"],["Set-Cookie","c=3"]]`,
+		} {
+			m := NewMultiMap[string, string]()
+			require.NoError(t, json.Unmarshal([]byte(data), m))
+			want := newHeaderTestMap()
+			require.Equal(t, want.Keys(), m.Keys())
+			for _, k := range want.Keys() {
+				require.Equal(t, want.GetAll(k), m.GetAll(k))
+			}
+		}
+
+		m := NewMultiMap[string, string]()
+		require.NoError(t, json.Unmarshal([]byte(`[["a","1"],["b","2"],["a","3"]]`), m))
+		require.Equal(t, []string{`a="1"`, `b="2"`, `a="3"`}, pairsOf(m))
+
+		require.NoError(t, json.Unmarshal([]byte(`null`), m))
+		require.Equal(t, 3, m.Len())
+		require.NoError(t, json.Unmarshal([]byte(`{"a":["4"]}`), m))
+		require.Equal(t, []string{"1", "3", "4"}, m.GetAll("a"))
+	})
+
+	t.Run("unmarshal round trip", func(t *testing.T) {
+		m := NewMultiMap[int, string]()
+		m.Add(3, "c")
+		m.Add(1, "a")
+		m.Add(3, "cc")
+		data, err := m.MarshalJSONPairs()
+		require.NoError(t, err)
+
+		decoded := NewMultiMap[int, string]()
+		require.NoError(t, json.Unmarshal(data, decoded))
+		require.Equal(t, []int{3, 1}, decoded.Keys())
+		require.Equal(t, []string{"c", "cc"}, decoded.GetAll(3))
+	})
+
+	t.Run("unmarshal nested maps", func(t *testing.T) {
+		m := NewMultiMap[string, any](WithNestedMaps())
+		require.NoError(t, json.Unmarshal([]byte(`[["a",{"z":1,"y":2}],["a",null]]`), m))
+		values := m.GetAll("a")
+		require.Len(t, values, 2)
+		require.Equal(t, []string{"z", "y"}, values[0].(*Map[string, any]).Keys())
+		require.Nil(t, values[1])
+	})
+
+	t.Run("unmarshal errors", func(t *testing.T) {
+		for _, data := range []string{
+			`1`,
+			`"a"`,
+			`{"a":1}`,
+			`{"a":[1]}`,
+			`[1]`,
+			`[["a"]]`,
+			`[["a","1","2"]]`,
+			`[[1,"a"]]`,
+			`{"a":["1"]`,
+		} {
+			m := NewMultiMap[string, string]()
+			require.Error(t, json.Unmarshal([]byte(data), m), data)
+		}
+
+		var typeErr *json.UnmarshalTypeError
+		require.ErrorAs(t, json.Unmarshal([]byte(`[1]`), NewMultiMap[string, string]()), &typeErr)
+
+		require.ErrorIs(t, json.Unmarshal([]byte(`{}`), NewMultiMap[struct{}, int]()), ErrUnsupportedKeyType)
+	})
+}
@@ -1,9 +1,25 @@
 package ordered
 
+import "time"
+
 type Option func(*option)
 
 type option struct {
-	capacity int
+	capacity   int
+	nestedMaps bool
+	now        func() time.Time
+
+	mergeStrategy MergeStrategy
+	sliceMerge    SliceMergeMode
+
+	collision CollisionPolicy
+
+	jsonPrefix       string
+	jsonIndent       string
+	jsonNoEscapeHTML bool
+	jsonSortKeys     bool
+	jsonFloatFormat  byte
+	jsonFloatPrec    int
 }
 
 func WithCapacity(capacity int) Option {
@@ -11,3 +27,72 @@ func WithCapacity(capacity int) Option {
 		o.capacity = capacity
 	}
 }
+
+// WithNestedMaps makes UnmarshalJSON decode JSON objects nested in any values
+// into *Map[string, any] instead of map[string]any, so their key order is preserved.
+func WithNestedMaps() Option {
+	return func(o *option) {
+		o.nestedMaps = true
+	}
+}
+
+// WithClock sets the clock used by ExpiringMap, defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(o *option) {
+		o.now = now
+	}
+}
+
+// WithMergeStrategy sets how DeepMerge resolves conflicting values, defaults to LastWins.
+func WithMergeStrategy(strategy MergeStrategy) Option {
+	return func(o *option) {
+		o.mergeStrategy = strategy
+	}
+}
+
+// WithSliceMerge sets how DeepMerge merges []any values, defaults to SliceReplace.
+func WithSliceMerge(mode SliceMergeMode) Option {
+	return func(o *option) {
+		o.sliceMerge = mode
+	}
+}
+
+// WithCollisionPolicy sets what BiMap.Set does when the value is already
+// mapped to another key, defaults to CollisionError.
+func WithCollisionPolicy(policy CollisionPolicy) Option {
+	return func(o *option) {
+		o.collision = policy
+	}
+}
+
+// WithIndent makes EncodeJSON write every element on a new line
+// beginning with prefix followed by copies of indent for the nesting depth.
+func WithIndent(prefix, indent string) Option {
+	return func(o *option) {
+		o.jsonPrefix = prefix
+		o.jsonIndent = indent
+	}
+}
+
+// WithEscapeHTML sets whether EncodeJSON escapes <, > and & in strings, defaults to true.
+func WithEscapeHTML(on bool) Option {
+	return func(o *option) {
+		o.jsonNoEscapeHTML = !on
+	}
+}
+
+// WithSortedKeys makes EncodeJSON write the keys of maps sorted instead of in order.
+func WithSortedKeys() Option {
+	return func(o *option) {
+		o.jsonSortKeys = true
+	}
+}
+
+// WithFloatFormat makes EncodeJSON format floats with strconv.FormatFloat(f, format, prec, bitSize)
+// instead of like encoding/json.
+func WithFloatFormat(format byte, prec int) Option {
+	return func(o *option) {
+		o.jsonFloatFormat = format
+		o.jsonFloatPrec = prec
+	}
+}
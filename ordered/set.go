@@ -2,93 +2,65 @@ package ordered
 
 import (
 	"encoding/json"
-	"maps"
-	"slices"
 )
 
 type Set[T comparable] struct {
-	keys []T
-	seen map[T]struct{}
+	m Map[T, struct{}]
 }
 
 func NewSet[T comparable](opts ...Option) *Set[T] {
-	var opt option
-	for _, o := range opts {
-		o(&opt)
-	}
-	return &Set[T]{
-		seen: make(map[T]struct{}, opt.capacity),
-		keys: make([]T, 0, opt.capacity),
-	}
+	return &Set[T]{m: *NewMap[T, struct{}](opts...)}
 }
 
 func (s *Set[T]) Add(key T) {
-	if _, ok := s.seen[key]; ok {
-		return
-	}
-	s.seen[key] = struct{}{}
-	s.keys = append(s.keys, key)
+	s.m.Set(key, struct{}{})
 }
 
 func (s *Set[T]) Remove(key T) {
-	idx := slices.Index(s.keys, key)
-	if idx != -1 {
-		s.keys = slices.Delete(s.keys, idx, idx+1)
-		delete(s.seen, key)
-	}
+	s.m.Del(key)
 }
 
 func (s *Set[T]) Contains(key T) bool {
-	_, ok := s.seen[key]
-	return ok
+	return s.m.Contains(key)
 }
 
 func (s *Set[T]) Len() int {
-	return len(s.keys)
+	return s.m.Len()
 }
 
 func (s *Set[T]) Iter(yield func(key T) bool) {
-	for _, key := range s.keys {
-		if !yield(key) {
-			break
-		}
-	}
+	s.m.IterKeys(yield)
 }
 
 func (s *Set[T]) Clear() {
-	clear(s.seen)
-	s.keys = s.keys[:0]
+	s.m.Clear()
 }
 
 func (s *Set[T]) Clone() *Set[T] {
-	return &Set[T]{
-		seen: maps.Clone(s.seen),
-		keys: slices.Clone(s.keys),
-	}
+	return &Set[T]{m: *s.m.Clone()}
 }
 
 func (s *Set[T]) Reverse() {
-	slices.Reverse(s.keys)
+	s.m.Reverse()
 }
 
 func (s *Set[T]) Values() []T {
-	return slices.Clone(s.keys)
+	return s.m.Keys()
 }
 
 func (s *Set[T]) MarshalJSON() ([]byte, error) {
-	return json.Marshal(s.keys)
+	return appendJSON(s)
 }
 
 func (s *Set[T]) UnmarshalJSON(data []byte) error {
-	err := json.Unmarshal(data, &s.keys)
+	var keys []T
+	err := json.Unmarshal(data, &keys)
 	if err != nil {
 		return err
 	}
-	if s.seen == nil {
-		s.seen = make(map[T]struct{}, len(s.keys))
-	}
-	for _, key := range s.keys {
-		s.seen[key] = struct{}{}
+	s.m.Clear()
+	for _, key := range keys {
+		s.Add(key)
 	}
 	return nil
 }
@@ -0,0 +1,54 @@
+package ordered
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet[string]()
+	s.Add("b")
+	s.Add("a")
+	s.Add("c")
+	s.Add("a")
+
+	require.Equal(t, 3, s.Len())
+	require.Equal(t, []string{"b", "a", "c"}, s.Values())
+	require.Equal(t, []string{"b", "a", "c"}, slices.Collect(s.Iter))
+
+	s.Remove("a")
+	s.Remove("nonexistent")
+	require.False(t, s.Contains("a"))
+	require.Equal(t, []string{"b", "c"}, s.Values())
+
+	s.Add("a")
+	require.Equal(t, []string{"b", "c", "a"}, s.Values())
+
+	clone := s.Clone()
+	s.Reverse()
+	require.Equal(t, []string{"a", "c", "b"}, s.Values())
+	require.Equal(t, []string{"b", "c", "a"}, clone.Values())
+
+	s.Clear()
+	require.Equal(t, 0, s.Len())
+	require.False(t, s.Contains("b"))
+}
+
+func TestSet_JSON(t *testing.T) {
+	s := NewSet[int]()
+	s.Add(3)
+	s.Add(1)
+	s.Add(2)
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Equal(t, `[3,1,2]`, string(data))
+
+	var decoded Set[int]
+	require.NoError(t, json.Unmarshal([]byte(`[5,4,5,6]`), &decoded))
+	require.Equal(t, []int{5, 4, 6}, decoded.Values())
+	require.True(t, decoded.Contains(4))
+}
@@ -0,0 +1,169 @@
+package ordered
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SyncMap is an ordered map that is safe for concurrent use.
+//
+// The entries are held in a Frozen map published through an atomic pointer.
+// Reads load the current version without locking, so they never wait for writers
+// or each other. Writes are serialized by a mutex and publish a new version in
+// O(log32 n) that shares most of its structure with the previous one.
+// Iteration and marshalling use the version current when they start,
+// so they observe a consistent state and never block writers while yielding.
+//
+// Compared to a Map guarded by a sync.RWMutex, reads scale with the number
+// of readers and are unaffected by writers, while writes allocate.
+//
+// The zero value is ready to use.
+type SyncMap[K comparable, V any] struct {
+	mu sync.Mutex // serializes writers
+	m  atomic.Pointer[Frozen[K, V]]
+
+	nestedMaps bool
+}
+
+// NewSyncMap creates an empty SyncMap. WithNestedMaps is the only option that applies,
+// to UnmarshalJSON. The others are ignored, including WithCapacity, as each version
+// is a persistent structure that grows with its entries and cannot be pre-sized.
+func NewSyncMap[K comparable, V any](opts ...Option) *SyncMap[K, V] {
+	var opt option
+	for _, o := range opts {
+		o(&opt)
+	}
+	return &SyncMap[K, V]{nestedMaps: opt.nestedMaps}
+}
+
+// load returns the current version.
+func (s *SyncMap[K, V]) load() *Frozen[K, V] {
+	if f := s.m.Load(); f != nil {
+		return f
+	}
+	return &Frozen[K, V]{}
+}
+
+func (s *SyncMap[K, V]) Set(key K, value V) {
+	s.mu.Lock()
+	s.m.Store(s.load().With(key, value))
+	s.mu.Unlock()
+}
+
+func (s *SyncMap[K, V]) Get(key K) V {
+	return s.load().Get(key)
+}
+
+func (s *SyncMap[K, V]) TryGet(key K) (V, bool) {
+	return s.load().TryGet(key)
+}
+
+func (s *SyncMap[K, V]) Contains(key K) bool {
+	return s.load().Contains(key)
+}
+
+// LoadOrStore returns the existing value for key if present.
+// Otherwise it stores value and returns it. loaded reports whether the value was loaded.
+func (s *SyncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if v, ok := s.load().TryGet(key); ok {
+		return v, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.load()
+	if v, ok := f.TryGet(key); ok {
+		return v, true
+	}
+	s.m.Store(f.With(key, value))
+	return value, false
+}
+
+// LoadAndDelete deletes key and returns its previous value if any.
+// loaded reports whether key was present.
+func (s *SyncMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.load()
+	value, loaded = f.TryGet(key)
+	if loaded {
+		s.m.Store(f.Without(key))
+	}
+	return value, loaded
+}
+
+func (s *SyncMap[K, V]) Del(key K) {
+	s.LoadAndDelete(key)
+}
+
+func (s *SyncMap[K, V]) Len() int {
+	return s.load().Len()
+}
+
+func (s *SyncMap[K, V]) Keys() []K {
+	return s.load().Keys()
+}
+
+func (s *SyncMap[K, V]) Values() []V {
+	return s.load().Values()
+}
+
+// Frozen returns the current version of the map in O(1).
+func (s *SyncMap[K, V]) Frozen() *Frozen[K, V] {
+	return s.load()
+}
+
+// Snapshot returns a mutable copy of the current version, see Frozen.Thaw.
+func (s *SyncMap[K, V]) Snapshot() *Map[K, V] {
+	return s.load().Thaw()
+}
+
+// Iter yields the entries of the version current when iteration starts.
+// yield may modify the SyncMap without affecting the iteration.
+func (s *SyncMap[K, V]) Iter(yield func(key K, value V) bool) {
+	s.load().Iter(yield)
+}
+
+func (s *SyncMap[K, V]) IterKeys(yield func(key K) bool) {
+	s.load().Iter(func(key K, _ V) bool {
+		return yield(key)
+	})
+}
+
+func (s *SyncMap[K, V]) IterValues(yield func(value V) bool) {
+	s.load().Iter(func(_ K, value V) bool {
+		return yield(value)
+	})
+}
+
+func (s *SyncMap[K, V]) Clear() {
+	s.mu.Lock()
+	s.m.Store(nil)
+	s.mu.Unlock()
+}
+
+// MarshalJSON marshals the current version of the map, see Map.MarshalJSON.
+func (s *SyncMap[K, V]) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return nil, ErrNilOrderedMap
+	}
+	return s.load().MarshalJSON()
+}
+
+// UnmarshalJSON decodes the JSON object into a temporary map
+// and then merges it into s atomically, see Map.UnmarshalJSON.
+func (s *SyncMap[K, V]) UnmarshalJSON(data []byte) error {
+	decoded := NewMap[K, V]()
+	decoded.nestedMaps = s.nestedMaps
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.load()
+	for k, v := range decoded.Iter {
+		f = f.With(k, v)
+	}
+	s.m.Store(f)
+	return nil
+}
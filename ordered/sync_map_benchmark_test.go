@@ -0,0 +1,104 @@
+package ordered
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// mutexMap is a Map guarded by a single RWMutex, used as a baseline.
+type mutexMap[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  *Map[K, V]
+}
+
+func (m *mutexMap[K, V]) Set(key K, value V) {
+	m.mu.Lock()
+	m.m.Set(key, value)
+	m.mu.Unlock()
+}
+
+func (m *mutexMap[K, V]) Get(key K) V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.m.Get(key)
+}
+
+func (m *mutexMap[K, V]) Del(key K) {
+	m.mu.Lock()
+	m.m.Del(key)
+	m.mu.Unlock()
+}
+
+func (m *mutexMap[K, V]) Iter(yield func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.m.Iter(yield)
+}
+
+type concurrentMap interface {
+	Set(key string, value int)
+	Get(key string) int
+	Del(key string)
+	Iter(yield func(key string, value int) bool)
+}
+
+func BenchmarkSyncMap(b *testing.B) {
+	const size = 1000
+
+	keys := make([]string, size*2)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+	}
+
+	impls := []struct {
+		name string
+		new  func() concurrentMap
+	}{
+		{"syncmap", func() concurrentMap { return NewSyncMap[string, int](WithCapacity(size)) }},
+		{"mutexmap", func() concurrentMap { return &mutexMap[string, int]{m: NewMap[string, int](WithCapacity(size))} }},
+	}
+
+	workloads := []struct {
+		name string
+		// percentage of writes and iterations, the rest are reads
+		writes, iters int
+	}{
+		{"read_only", 0, 0},
+		{"read_mostly", 5, 0},
+		{"mixed", 50, 0},
+		{"with_iter", 10, 1},
+	}
+
+	for _, wl := range workloads {
+		for _, impl := range impls {
+			b.Run(wl.name+"/"+impl.name, func(b *testing.B) {
+				m := impl.new()
+				for i := range size {
+					m.Set(keys[i], i)
+				}
+
+				b.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						key := keys[i%len(keys)]
+						switch op := i % 100; {
+						case op < wl.iters:
+							for range m.Iter {
+							}
+						case op < wl.iters+wl.writes:
+							if i%2 == 0 {
+								m.Set(key, i)
+							} else {
+								m.Del(key)
+							}
+						default:
+							m.Get(key)
+						}
+						i++
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ordered
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMap(t *testing.T) {
+	var sm SyncMap[string, int]
+	sm.Set("b", 2)
+	sm.Set("a", 1)
+	sm.Set("c", 3)
+
+	require.Equal(t, 3, sm.Len())
+	require.Equal(t, 1, sm.Get("a"))
+	require.True(t, sm.Contains("c"))
+	require.Equal(t, []string{"b", "a", "c"}, sm.Keys())
+	require.Equal(t, []int{2, 1, 3}, sm.Values())
+
+	actual, loaded := sm.LoadOrStore("a", 10)
+	require.True(t, loaded)
+	require.Equal(t, 1, actual)
+	actual, loaded = sm.LoadOrStore("d", 4)
+	require.False(t, loaded)
+	require.Equal(t, 4, actual)
+
+	value, loaded := sm.LoadAndDelete("b")
+	require.True(t, loaded)
+	require.Equal(t, 2, value)
+	_, loaded = sm.LoadAndDelete("b")
+	require.False(t, loaded)
+
+	sm.Del("c")
+	require.Equal(t, []string{"a", "d"}, sm.Keys())
+
+	sm.Clear()
+	require.Equal(t, 0, sm.Len())
+}
+
+func TestSyncMap_IterSnapshot(t *testing.T) {
+	sm := NewSyncMap[int, int]()
+	for i := range 5 {
+		sm.Set(i, i)
+	}
+
+	var keys []int
+	for k := range sm.Iter {
+		keys = append(keys, k)
+		// modifying the map while iterating neither deadlocks nor affects the iteration
+		sm.Del(k + 1)
+		sm.Set(k+10, k)
+	}
+	require.Equal(t, []int{0, 1, 2, 3, 4}, keys)
+	require.Equal(t, []int{0, 10, 11, 12, 13, 14}, sm.Keys())
+
+	// versions and snapshots are not affected by later writes
+	version, snapshot := sm.Frozen(), sm.Snapshot()
+	sm.Set(0, 100)
+	sm.Del(10)
+	require.Equal(t, []int{0, 10, 11, 12, 13, 14}, version.Keys())
+	require.Equal(t, 0, version.Get(0))
+	require.Equal(t, []int{0, 10, 11, 12, 13, 14}, snapshot.Keys())
+	snapshot.Set(20, 20)
+	require.Equal(t, []int{0, 11, 12, 13, 14}, sm.Keys())
+	require.Equal(t, 100, sm.Get(0))
+}
+
+func TestSyncMap_JSON(t *testing.T) {
+	sm := NewSyncMap[string, any](WithNestedMaps())
+	require.NoError(t, json.Unmarshal([]byte(`{"z":1,"a":{"y":2,"x":3}}`), sm))
+	require.Equal(t, []string{"z", "a"}, sm.Keys())
+	require.IsType(t, &Map[string, any]{}, sm.Get("a"))
+
+	data, err := json.Marshal(sm)
+	require.NoError(t, err)
+	require.Equal(t, `{"z":1,"a":{"y":2,"x":3}}`, string(data))
+}
+
+func TestSyncMap_Concurrent(t *testing.T) {
+	const (
+		writers = 8
+		perG    = 500
+	)
+
+	sm := NewSyncMap[string, int]()
+	var wg sync.WaitGroup
+	for g := range writers {
+		wg.Go(func() {
+			for i := range perG {
+				key := strconv.Itoa(g) + "-" + strconv.Itoa(i)
+				sm.Set(key, i)
+				if i%2 == 1 {
+					sm.Del(key)
+				}
+			}
+		})
+		wg.Go(func() {
+			for range perG / 50 {
+				n := 0
+				for range sm.Iter {
+					n++
+				}
+				_, err := sm.MarshalJSON()
+				require.NoError(t, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	require.Equal(t, writers*perG/2, sm.Len())
+	for g := range writers {
+		prev := -1
+		// each writer's keys keep their relative insertion order
+		for k, v := range sm.Iter {
+			if k[:len(strconv.Itoa(g))+1] != strconv.Itoa(g)+"-" {
+				continue
+			}
+			require.Greater(t, v, prev)
+			prev = v
+		}
+	}
+}
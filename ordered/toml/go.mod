@@ -0,0 +1,17 @@
+module github.com/yusing/ds/ordered/toml
+
+go 1.25.1
+
+require (
+	github.com/pelletier/go-toml/v2 v2.2.4
+	github.com/stretchr/testify v1.11.1
+	github.com/yusing/ds v0.0.0-0000000000000-000000000000
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/yusing/ds => ../..
@@ -0,0 +1,375 @@
+// Package toml encodes and decodes TOML documents with ordered maps, keeping the order
+// of keys, tables and arrays of tables.
+package toml
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/yusing/ds/ordered"
+)
+
+const maxDepth = 1000
+
+// ErrUnsupportedType is returned by Marshal for values that have no TOML representation.
+var ErrUnsupportedType = errors.New("toml: unsupported type")
+
+// Marshal returns the TOML encoding of m.
+//
+// Nested *ordered.Map[string, any] are written as tables, and slices of them as arrays of tables,
+// in insertion order. TOML requires the plain key/values of a table to precede its sub-tables,
+// so they are written first, each group keeping its own order. Inside arrays, maps are written
+// as inline tables. Go maps are written with sorted keys.
+//
+// time.Time is written as an offset date-time, toml.LocalDate, toml.LocalTime and
+// toml.LocalDateTime as local ones, and types implementing encoding.TextMarshaler as strings.
+// TOML has no null, so nil values are omitted from tables and are an error in arrays.
+func Marshal(m *ordered.Map[string, any]) ([]byte, error) {
+	if m == nil {
+		return nil, ordered.ErrNilOrderedMap
+	}
+	var e encoder
+	if err := e.table(nil, m, false, 0); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+}
+
+// table writes the key/values of a table, then its sub-tables. path is nil for the root table.
+func (e *encoder) table(path []string, v any, arrayItem bool, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("toml: exceeded max depth of %d, the value may be cyclic", maxDepth)
+	}
+	keys, values := tableEntries(v)
+
+	hasValues := false
+	var subTables []int
+	for i, value := range values {
+		switch {
+		case isNil(value):
+		case isTable(value) || isArrayOfTables(value):
+			subTables = append(subTables, i)
+		default:
+			hasValues = true
+		}
+	}
+
+	// tables holding only sub-tables are defined implicitly by their headers
+	if path != nil && (arrayItem || hasValues || len(subTables) == 0) {
+		if e.buf.Len() > 0 {
+			e.buf.WriteByte('\n')
+		}
+		if arrayItem {
+			e.buf.WriteString("[[")
+		} else {
+			e.buf.WriteByte('[')
+		}
+		for i, key := range path {
+			if i > 0 {
+				e.buf.WriteByte('.')
+			}
+			writeKey(&e.buf, key)
+		}
+		if arrayItem {
+			e.buf.WriteString("]]")
+		} else {
+			e.buf.WriteByte(']')
+		}
+		e.buf.WriteByte('\n')
+	}
+
+	for i, value := range values {
+		if isNil(value) || isTable(value) || isArrayOfTables(value) {
+			continue
+		}
+		writeKey(&e.buf, keys[i])
+		e.buf.WriteString(" = ")
+		if err := e.value(keys[i], value, depth+1); err != nil {
+			return err
+		}
+		e.buf.WriteByte('\n')
+	}
+
+	for _, i := range subTables {
+		subPath := append(path[:len(path):len(path)], keys[i])
+		if isTable(values[i]) {
+			if err := e.table(subPath, values[i], false, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, item := range arrayItems(values[i]) {
+			if err := e.table(subPath, item, true, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// value writes an inline value, key is used in the error messages.
+func (e *encoder) value(key string, v any, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("toml: exceeded max depth of %d, the value may be cyclic", maxDepth)
+	}
+	switch v := v.(type) {
+	case time.Time:
+		e.buf.WriteString(v.Format(time.RFC3339Nano))
+		return nil
+	case toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		e.buf.WriteString(v.(fmt.Stringer).String())
+		return nil
+	case encoding.TextMarshaler:
+		if !isNil(v) {
+			text, err := v.MarshalText()
+			if err != nil {
+				return err
+			}
+			writeString(&e.buf, string(text))
+			return nil
+		}
+	}
+
+	if isTable(v) {
+		keys, values := tableEntries(v)
+		e.buf.WriteByte('{')
+		n := 0
+		for i, value := range values {
+			if isNil(value) {
+				continue
+			}
+			if n > 0 {
+				e.buf.WriteByte(',')
+			}
+			n++
+			e.buf.WriteByte(' ')
+			writeKey(&e.buf, keys[i])
+			e.buf.WriteString(" = ")
+			if err := e.value(keys[i], value, depth+1); err != nil {
+				return err
+			}
+		}
+		if n > 0 {
+			e.buf.WriteByte(' ')
+		}
+		e.buf.WriteByte('}')
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return fmt.Errorf("toml: cannot encode nil in the array of key %q", key)
+		}
+		return e.value(key, rv.Elem().Interface(), depth+1)
+	case reflect.Bool:
+		e.buf.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf.WriteString(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return fmt.Errorf("toml: integer %d of key %q overflows int64", rv.Uint(), key)
+		}
+		e.buf.WriteString(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		writeFloat(&e.buf, rv.Float(), rv.Type().Bits())
+	case reflect.String:
+		writeString(&e.buf, rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return fmt.Errorf("toml: cannot encode nil in the array of key %q", key)
+		}
+		e.buf.WriteByte('[')
+		for i := range rv.Len() {
+			if i > 0 {
+				e.buf.WriteString(", ")
+			}
+			item := rv.Index(i).Interface()
+			if isNil(item) {
+				return fmt.Errorf("toml: cannot encode nil in the array of key %q", key)
+			}
+			if err := e.value(key, item, depth+1); err != nil {
+				return err
+			}
+		}
+		e.buf.WriteByte(']')
+	default:
+		return fmt.Errorf("%w %T of key %q", ErrUnsupportedType, v, key)
+	}
+	return nil
+}
+
+// isTable reports whether v is written as a table.
+func isTable(v any) bool {
+	switch v.(type) {
+	case *ordered.Map[string, any], ordered.Map[string, any], map[string]any:
+		return !isNil(v)
+	}
+	return false
+}
+
+// isArrayOfTables reports whether v is a non-empty slice or array of tables.
+func isArrayOfTables(v any) bool {
+	items := arrayItems(v)
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if !isTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func arrayItems(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case []*ordered.Map[string, any]:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	case []map[string]any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	}
+	return nil
+}
+
+// tableEntries returns the keys and values of a table in the order they are written.
+func tableEntries(v any) ([]string, []any) {
+	switch v := v.(type) {
+	case *ordered.Map[string, any]:
+		return v.Keys(), v.Values()
+	case ordered.Map[string, any]:
+		return v.Keys(), v.Values()
+	case map[string]any:
+		keys := slices.Sorted(maps.Keys(v))
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			values[i] = v[key]
+		}
+		return keys, values
+	}
+	return nil, nil
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func isBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func writeKey(buf *bytes.Buffer, key string) {
+	if isBareKey(key) {
+		buf.WriteString(key)
+	} else {
+		writeString(buf, key)
+	}
+}
+
+const hex = "0123456789ABCDEF"
+
+// writeString writes s as a TOML basic string.
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\b':
+			buf.WriteString(`\b`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\f':
+			buf.WriteString(`\f`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c < 0x20 || c == 0x7f:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		case c < utf8.RuneSelf:
+			buf.WriteByte(c)
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(`\uFFFD`)
+			} else {
+				buf.WriteString(s[i : i+size])
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	buf.WriteByte('"')
+}
+
+// writeFloat writes f so that it is read back as a float, with an exponent only for large or small values.
+func writeFloat(buf *bytes.Buffer, f float64, bits int) {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString("nan")
+		return
+	case math.IsInf(f, 1):
+		buf.WriteString("inf")
+		return
+	case math.IsInf(f, -1):
+		buf.WriteString("-inf")
+		return
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b := strconv.AppendFloat(buf.AvailableBuffer(), f, format, -1, bits)
+	if !bytes.ContainsAny(b, ".e") {
+		b = append(b, ".0"...)
+	}
+	buf.Write(b)
+}
@@ -0,0 +1,143 @@
+package toml
+
+import (
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/yusing/ds/ordered"
+)
+
+func newMap(kv ...any) *ordered.Map[string, any] {
+	m := ordered.NewMap[string, any]()
+	for i := 0; i < len(kv); i += 2 {
+		m.Set(kv[i].(string), kv[i+1])
+	}
+	return m
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("scalars", func(t *testing.T) {
+		m := newMap(
+			"zeta", "z",
+			"alpha", 1,
+			"bool", true,
+			"float", 1.0,
+			"small", 1e-7,
+			"float32", float32(0.1),
+			"inf", math.Inf(-1),
+			"big", uint64(math.MaxInt64),
+			"at", time.Date(2024, 5, 6, 7, 8, 9, 500, time.FixedZone("", -7*3600)),
+			"date", toml.LocalDate{Year: 2024, Month: 5, Day: 6},
+			"addr", netip.MustParseAddr("::1"),
+			"list", []any{1, "two", []int{3}, newMap("k", "v", "x", nil)},
+			"empty", []any{},
+			"none", nil,
+			"nil map", (*ordered.Map[string, any])(nil),
+			"quoted key", "a \"quote\"\n\x01\x7f",
+		)
+		data, err := Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `zeta = "z"
+alpha = 1
+bool = true
+float = 1.0
+small = 1e-07
+float32 = 0.1
+inf = -inf
+big = 9223372036854775807
+at = 2024-05-06T07:08:09.0000005-07:00
+date = 2024-05-06
+addr = "::1"
+list = [1, "two", [3], { k = "v" }]
+empty = []
+"quoted key" = "a \"quote\"\n\u0001\u007F"
+`, string(data))
+	})
+
+	t.Run("tables in insertion order", func(t *testing.T) {
+		m := newMap(
+			"title", "doc",
+			"servers", newMap(
+				"zeta", newMap("ip", "10.0.0.2"),
+				"alpha", newMap("ip", "10.0.0.1", "ports", []int{80, 443}),
+			),
+			"owner", newMap("name", "me", "nested", newMap()),
+			"version", 2,
+		)
+		data, err := Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `title = "doc"
+version = 2
+
+[servers.zeta]
+ip = "10.0.0.2"
+
+[servers.alpha]
+ip = "10.0.0.1"
+ports = [80, 443]
+
+[owner]
+name = "me"
+
+[owner.nested]
+`, string(data))
+	})
+
+	t.Run("arrays of tables", func(t *testing.T) {
+		m := newMap(
+			"products", []any{
+				newMap("name", "b", "color", newMap("r", 1)),
+				newMap(),
+				newMap("name", "a", "tags", []any{newMap("k", "v"), map[string]any{"z": 1, "y": 2}}),
+			},
+			"plain", []*ordered.Map[string, any]{newMap("x", 1)},
+		)
+		data, err := Marshal(m)
+		require.NoError(t, err)
+		require.Equal(t, `[[products]]
+name = "b"
+
+[products.color]
+r = 1
+
+[[products]]
+
+[[products]]
+name = "a"
+
+[[products.tags]]
+k = "v"
+
+[[products.tags]]
+y = 2
+z = 1
+
+[[plain]]
+x = 1
+`, string(data))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Marshal(nil)
+		require.ErrorIs(t, err, ordered.ErrNilOrderedMap)
+
+		_, err = Marshal(newMap("ch", make(chan int)))
+		require.ErrorIs(t, err, ErrUnsupportedType)
+		require.EqualError(t, err, `toml: unsupported type chan int of key "ch"`)
+
+		_, err = Marshal(newMap("list", []any{1, nil}))
+		require.EqualError(t, err, `toml: cannot encode nil in the array of key "list"`)
+
+		_, err = Marshal(newMap("big", uint64(math.MaxUint64)))
+		require.EqualError(t, err, `toml: integer 18446744073709551615 of key "big" overflows int64`)
+
+		cyclic := newMap()
+		cyclic.Set("self", cyclic)
+		_, err = Marshal(cyclic)
+		require.ErrorContains(t, err, "exceeded max depth")
+	})
+}
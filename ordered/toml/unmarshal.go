@@ -0,0 +1,161 @@
+package toml
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/yusing/ds/ordered"
+)
+
+// Unmarshal decodes the TOML document in data into m, keeping the order of the document.
+//
+// Tables and inline tables are decoded as *ordered.Map[string, any], arrays and arrays of
+// tables as []any. Other values are decoded like toml.Unmarshal does into an interface:
+// int64, float64, string, bool, time.Time, toml.LocalDate, toml.LocalTime and toml.LocalDateTime.
+// Keys already in m keep their position and are overwritten.
+func Unmarshal(data []byte, m *ordered.Map[string, any]) error {
+	if m == nil {
+		return ordered.ErrNilOrderedMap
+	}
+
+	// validation and value conversion are left to go-toml, the parser only gives the order of the keys
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	order, err := documentOrder(data)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range order.keys {
+		m.Set(key, build(doc[key], order.fields[key]))
+	}
+	return nil
+}
+
+// keyOrder records the order of the keys of a table, and of the items of an array.
+type keyOrder struct {
+	keys   []string
+	fields map[string]*keyOrder
+	items  []*keyOrder
+}
+
+// field returns the order of key, adding key to the table if it is new.
+func (o *keyOrder) field(key string) *keyOrder {
+	if f, ok := o.fields[key]; ok {
+		return f
+	}
+	if o.fields == nil {
+		o.fields = make(map[string]*keyOrder)
+	}
+	f := new(keyOrder)
+	o.keys = append(o.keys, key)
+	o.fields[key] = f
+	return f
+}
+
+// table returns the table a header or a dotted key refers to,
+// which is the last item for arrays of tables.
+func (o *keyOrder) table(key string) *keyOrder {
+	f := o.field(key)
+	if len(f.items) > 0 {
+		return f.items[len(f.items)-1]
+	}
+	return f
+}
+
+func (o *keyOrder) addItem() *keyOrder {
+	item := new(keyOrder)
+	o.items = append(o.items, item)
+	return item
+}
+
+// documentOrder walks the expressions of a valid TOML document and records the order of its keys.
+func documentOrder(data []byte) (*keyOrder, error) {
+	root := new(keyOrder)
+	current := root
+
+	var p unstable.Parser
+	p.Reset(data)
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			keys := keyParts(expr)
+			current = root
+			for _, key := range keys[:len(keys)-1] {
+				current = current.table(key)
+			}
+			last := keys[len(keys)-1]
+			if expr.Kind == unstable.Table {
+				current = current.table(last)
+			} else {
+				current = current.field(last).addItem()
+			}
+		case unstable.KeyValue:
+			keyValueOrder(current, expr)
+		}
+	}
+	return root, p.Error()
+}
+
+func keyParts(n *unstable.Node) []string {
+	var keys []string
+	it := n.Key()
+	for it.Next() {
+		keys = append(keys, string(it.Node().Data))
+	}
+	return keys
+}
+
+func keyValueOrder(o *keyOrder, kv *unstable.Node) {
+	keys := keyParts(kv)
+	for _, key := range keys[:len(keys)-1] {
+		o = o.table(key)
+	}
+	valueOrder(o.field(keys[len(keys)-1]), kv.Value())
+}
+
+func valueOrder(o *keyOrder, v *unstable.Node) {
+	switch v.Kind {
+	case unstable.InlineTable:
+		it := v.Children()
+		for it.Next() {
+			keyValueOrder(o, it.Node())
+		}
+	case unstable.Array:
+		it := v.Children()
+		for it.Next() {
+			valueOrder(o.addItem(), it.Node())
+		}
+	}
+}
+
+// build converts the tables decoded by go-toml to ordered maps in the recorded order.
+func build(v any, o *keyOrder) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := ordered.NewMap[string, any](ordered.WithCapacity(len(v)))
+		if o == nil || len(o.keys) != len(v) {
+			// should not happen, fall back to a deterministic order
+			o = &keyOrder{keys: slices.Sorted(maps.Keys(v))}
+		}
+		for _, key := range o.keys {
+			m.Set(key, build(v[key], o.fields[key]))
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			var itemOrder *keyOrder
+			if o != nil && i < len(o.items) {
+				itemOrder = o.items[i]
+			}
+			v[i] = build(item, itemOrder)
+		}
+		return v
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package toml
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/yusing/ds/ordered"
+)
+
+const document = `# comment
+title = "doc"
+zeta.b = 2
+zeta.a = 1
+point = { y = 2, x = 1, z = [{ k = 1, j = 2 }] }
+when = 1979-05-27T07:32:00Z
+day = 1979-05-27
+
+[servers.zeta]
+ip = "10.0.0.2"
+
+[servers.alpha]
+ip = "10.0.0.1"
+
+[[products]]
+name = "b"
+sku = 2
+
+[products.color]
+r = 1
+
+[[products]]
+sku = 1
+name = "a"
+
+[servers]
+"quoted key" = true
+`
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("keeps document order", func(t *testing.T) {
+		m := ordered.NewMap[string, any]()
+		require.NoError(t, Unmarshal([]byte(document), m))
+		require.Equal(t, []string{"title", "zeta", "point", "when", "day", "servers", "products"}, m.Keys())
+
+		require.Equal(t, "doc", m.Get("title"))
+		require.Equal(t, time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC), m.Get("when"))
+		require.Equal(t, toml.LocalDate{Year: 1979, Month: 5, Day: 27}, m.Get("day"))
+
+		zeta := m.Get("zeta").(*ordered.Map[string, any])
+		require.Equal(t, []string{"b", "a"}, zeta.Keys())
+		require.Equal(t, []any{int64(2), int64(1)}, zeta.Values())
+
+		point := m.Get("point").(*ordered.Map[string, any])
+		require.Equal(t, []string{"y", "x", "z"}, point.Keys())
+		require.Equal(t, []string{"k", "j"}, point.Get("z").([]any)[0].(*ordered.Map[string, any]).Keys())
+
+		servers := m.Get("servers").(*ordered.Map[string, any])
+		require.Equal(t, []string{"zeta", "alpha", "quoted key"}, servers.Keys())
+
+		products := m.Get("products").([]any)
+		require.Len(t, products, 2)
+		first := products[0].(*ordered.Map[string, any])
+		require.Equal(t, []string{"name", "sku", "color"}, first.Keys())
+		require.Equal(t, []string{"r"}, first.Get("color").(*ordered.Map[string, any]).Keys())
+		require.Equal(t, []string{"sku", "name"}, products[1].(*ordered.Map[string, any]).Keys())
+	})
+
+	t.Run("into existing map", func(t *testing.T) {
+		m := newMap("version", 1, "title", "old")
+		require.NoError(t, Unmarshal([]byte("extra = 1\ntitle = \"new\"\n"), m))
+		require.Equal(t, []string{"version", "title", "extra"}, m.Keys())
+		require.Equal(t, "new", m.Get("title"))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		m := ordered.NewMap[string, any]()
+		require.NoError(t, Unmarshal([]byte(document), m))
+		data, err := Marshal(m)
+		require.NoError(t, err)
+
+		again := ordered.NewMap[string, any]()
+		require.NoError(t, Unmarshal(data, again))
+		// key/values are written before the tables
+		require.Equal(t, []string{"title", "when", "day", "zeta", "point", "servers", "products"}, again.Keys())
+		require.Equal(t, []string{"y", "x", "z"}, again.Get("point").(*ordered.Map[string, any]).Keys())
+		data2, err := Marshal(again)
+		require.NoError(t, err)
+		require.Equal(t, string(data), string(data2))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		require.ErrorIs(t, Unmarshal([]byte("a = 1"), nil), ordered.ErrNilOrderedMap)
+
+		m := ordered.NewMap[string, any]()
+		require.EqualError(t, Unmarshal([]byte("a = 1\na = 2"), m), "toml: key a is already defined")
+		var decodeErr *toml.DecodeError
+		require.ErrorAs(t, Unmarshal([]byte("a = "), m), &decodeErr)
+		require.Zero(t, m.Len())
+	})
+}
@@ -0,0 +1,35 @@
+package ordered
+
+import (
+	"github.com/yusing/ds/ordered"
+)
+
+// Equal reports whether a and b hold the same entries in the same order.
+func Equal[K, V comparable](a, b *Map[K, V]) bool {
+	return ordered.Equal(&a.omap, &b.omap)
+}
+
+// EqualFunc is like Equal but compares values with eq.
+func EqualFunc[K comparable, V1, V2 any](a *Map[K, V1], b *Map[K, V2], eq func(V1, V2) bool) bool {
+	return ordered.EqualFunc(&a.omap, &b.omap, eq)
+}
+
+// EqualUnordered reports whether a and b hold the same entries, in any order.
+func EqualUnordered[K, V comparable](a, b *Map[K, V]) bool {
+	return ordered.EqualUnordered(&a.omap, &b.omap)
+}
+
+// EqualUnorderedFunc is like EqualUnordered but compares values with eq.
+func EqualUnorderedFunc[K comparable, V1, V2 any](a *Map[K, V1], b *Map[K, V2], eq func(V1, V2) bool) bool {
+	return ordered.EqualUnorderedFunc(&a.omap, &b.omap, eq)
+}
+
+// Mismatch returns a report of the differences between a and b, see ordered.Mismatch.
+func Mismatch[K, V comparable](a, b *Map[K, V]) string {
+	return ordered.Mismatch(&a.omap, &b.omap)
+}
+
+// MismatchUnordered is like Mismatch but ignores the order, see ordered.MismatchUnordered.
+func MismatchUnordered[K, V comparable](a, b *Map[K, V]) string {
+	return ordered.MismatchUnordered(&a.omap, &b.omap)
+}
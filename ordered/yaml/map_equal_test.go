@@ -0,0 +1,24 @@
+package ordered
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEqual(t *testing.T) {
+	a := NewMap[string, int]()
+	a.Set("x", 1)
+	a.Set("y", 2)
+	b := NewMap[string, int]()
+	b.Set("y", 2)
+	b.Set("x", 1)
+
+	require.True(t, Equal(a, a))
+	require.False(t, Equal(a, b))
+	require.True(t, EqualUnordered(a, b))
+	require.True(t, EqualFunc(a, a, func(x, y int) bool { return x == y }))
+	require.True(t, EqualUnorderedFunc(a, b, func(x, y int) bool { return x == y }))
+	require.Equal(t, "> y: [1] -> [0]\n", Mismatch(a, b))
+	require.Empty(t, MismatchUnordered(a, b))
+}
@@ -36,20 +36,15 @@ func (o *Map[K, V]) MarshalYAML() ([]byte, error) {
 		return []byte("{}"), nil
 	}
 
-	keys := o.Keys()
-
-	// can just convert it directly to string slice to avoid unnecessary allocation
-	strKeys := *(*[]string)(unsafe.Pointer(&keys))
-
 	// handle root keys to preserve the insertion order
 	buf := bytes.NewBuffer(make([]byte, 0, o.Len()*20))
-	for i, keyStr := range strKeys {
+	for key, v := range o.Iter {
 		// write YAML key (quote conservatively to avoid special-char pitfalls)
-		writeYAMLQuotedString(buf, keyStr)
+		// K is a string kind, it can be converted directly to avoid unnecessary allocation
+		writeYAMLQuotedString(buf, *(*string)(unsafe.Pointer(&key)))
 		buf.WriteByte(':')
 
 		// encode value using YAML so nested structs/slices are handled correctly
-		v := o.Get(keys[i])
 		vb, err := yaml.Marshal(v)
 		if err != nil {
 			return nil, err